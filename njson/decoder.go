@@ -0,0 +1,826 @@
+package njson
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/influx6/npkg"
+)
+
+var (
+	// ErrUnexpectedEOF is returned when the underline reader or byte slice
+	// ends before a complete json value was read.
+	ErrUnexpectedEOF = errors.New("unexpected end of json input")
+
+	nullLiteral  = []byte("null")
+	trueLiteral  = []byte("true")
+	falseLiteral = []byte("false")
+)
+
+const minReadSize = 512
+
+var _ npkg.Decoder = (*Decoder)(nil)
+
+// SyntaxError is returned when the decoder meets content which is not valid
+// json for the value it was asked to decode.
+type SyntaxError struct {
+	Message string
+	Offset  int64
+}
+
+// Error implements the error interface.
+func (s *SyntaxError) Error() string {
+	return fmt.Sprintf("njson: %s at offset %d", s.Message, s.Offset)
+}
+
+// Decoder implements the npkg.Decoder interface, reading json values from
+// a byte slice or a io.Reader.
+//
+// Decoder does not use reflection, instead it drives the DecodeKey and
+// DecodeIndex methods of npkg.DecodableObject and npkg.DecodableList, with
+// each call expected to consume the current value by calling the relevant
+// method on the decoder. Values not consumed by a call are skipped.
+//
+// Content is read lazily from the reader into a internal buffer which gets
+// compacted as values are consumed, only lists are buffered in full, as their
+// total item count is provided to npkg.DecodableList.DecodeIndex.
+//
+// A Decoder is not safe for concurrent use.
+type Decoder struct {
+	r       io.Reader
+	buf     []byte
+	scratch []byte
+	pos     int
+	base    int64
+	err     error
+}
+
+// NewDecoder returns a new Decoder reading from provided reader.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r, buf: make([]byte, 0, minReadSize)}
+}
+
+// NewBytesDecoder returns a new Decoder reading from provided byte slice.
+//
+// The decoder does not copy the slice, so it must not be modified
+// while in use by decoder.
+func NewBytesDecoder(b []byte) *Decoder {
+	return &Decoder{buf: b, err: io.EOF}
+}
+
+// DecodeBytes decodes the first json value in provided slice into v
+// using npkg.Decode.
+func DecodeBytes(b []byte, v interface{}) error {
+	return NewBytesDecoder(b).Decode(v)
+}
+
+// Reset resets decoder to read from provided reader, re-using it's
+// internal buffer.
+func (d *Decoder) Reset(r io.Reader) {
+	// never read into a slice provided through NewBytesDecoder.
+	if d.r == nil {
+		d.buf = make([]byte, 0, minReadSize)
+	}
+
+	d.r = r
+	d.err = nil
+	d.pos = 0
+	d.base = 0
+	d.buf = d.buf[:0]
+}
+
+// Decode decodes the next json value from the underline source into v
+// using npkg.Decode.
+func (d *Decoder) Decode(v interface{}) error {
+	return npkg.Decode(d, v)
+}
+
+// More returns true/false if there is another value to be decoded
+// from underline source.
+func (d *Decoder) More() bool {
+	_, err := d.peek()
+	return err == nil
+}
+
+// Offset returns the current offset of decoder in underline source.
+func (d *Decoder) Offset() int64 {
+	return d.base + int64(d.pos)
+}
+
+// Object decodes the next json object calling DecodeKey for each key found.
+// A null value leaves the object untouched.
+func (d *Decoder) Object(o npkg.DecodableObject) error {
+	c, err := d.peek()
+	if err != nil {
+		return err
+	}
+	if c == 'n' {
+		return d.readNull()
+	}
+	if c != '{' {
+		return d.syntaxErr("expected object but found %q", c)
+	}
+	d.pos++
+
+	c, err = d.peekMore()
+	if err != nil {
+		return err
+	}
+	if c == '}' {
+		d.pos++
+		return nil
+	}
+
+	for {
+		if c != '"' {
+			return d.syntaxErr("expected object key but found %q", c)
+		}
+
+		key, err := d.readString()
+		if err != nil {
+			return err
+		}
+		var k = string(key)
+
+		if c, err = d.peekMore(); err != nil {
+			return err
+		}
+		if c != ':' {
+			return d.syntaxErr("expected ':' after object key but found %q", c)
+		}
+		d.pos++
+
+		if _, err = d.peekMore(); err != nil {
+			return err
+		}
+
+		var start = d.Offset()
+		if err := o.DecodeKey(d, k); err != nil {
+			return err
+		}
+		if d.Offset() == start {
+			if err := d.skipValue(); err != nil {
+				return err
+			}
+		}
+
+		if c, err = d.peekMore(); err != nil {
+			return err
+		}
+		switch c {
+		case ',':
+			d.pos++
+			if c, err = d.peekMore(); err != nil {
+				return err
+			}
+		case '}':
+			d.pos++
+			return nil
+		default:
+			return d.syntaxErr("expected ',' or '}' after object value but found %q", c)
+		}
+	}
+}
+
+// List decodes the next json list calling DecodeIndex for each item found.
+// A null value leaves the list untouched.
+func (d *Decoder) List(l npkg.DecodableList) error {
+	c, err := d.peek()
+	if err != nil {
+		return err
+	}
+	if c == 'n' {
+		return d.readNull()
+	}
+	if c != '[' {
+		return d.syntaxErr("expected list but found %q", c)
+	}
+
+	total, err := d.countItems()
+	if err != nil {
+		return err
+	}
+
+	d.pos++
+	for index := int64(0); index < total; index++ {
+		if _, err = d.peekMore(); err != nil {
+			return err
+		}
+
+		var start = d.Offset()
+		if err := l.DecodeIndex(d, index, total); err != nil {
+			return err
+		}
+		if d.Offset() == start {
+			if err := d.skipValue(); err != nil {
+				return err
+			}
+		}
+
+		if c, err = d.peekMore(); err != nil {
+			return err
+		}
+		if c == ',' {
+			d.pos++
+		}
+	}
+
+	if c, err = d.peekMore(); err != nil {
+		return err
+	}
+	if c != ']' {
+		return d.syntaxErr("expected ']' after list items but found %q", c)
+	}
+	d.pos++
+	return nil
+}
+
+// String decodes the next json string value.
+func (d *Decoder) String(v *string) error {
+	c, err := d.peek()
+	if err != nil {
+		return err
+	}
+	if c == 'n' {
+		return d.readNull()
+	}
+	if c != '"' {
+		return d.syntaxErr("expected string but found %q", c)
+	}
+
+	content, err := d.readString()
+	if err != nil {
+		return err
+	}
+	*v = string(content)
+	return nil
+}
+
+// Hex decodes the next json string value, which holds a hex string.
+func (d *Decoder) Hex(v *string) error {
+	return d.String(v)
+}
+
+// Bool decodes the next json boolean value.
+func (d *Decoder) Bool(v *bool) error {
+	c, err := d.peek()
+	if err != nil {
+		return err
+	}
+
+	switch c {
+	case 'n':
+		return d.readNull()
+	case 't':
+		if err := d.readLiteral(trueLiteral); err != nil {
+			return err
+		}
+		*v = true
+		return nil
+	case 'f':
+		if err := d.readLiteral(falseLiteral); err != nil {
+			return err
+		}
+		*v = false
+		return nil
+	}
+	return d.syntaxErr("expected boolean but found %q", c)
+}
+
+// Int decodes the next json number as a int.
+func (d *Decoder) Int(v *int) error {
+	return d.readInt(strconv.IntSize, func(n int64) {
+		*v = int(n)
+	})
+}
+
+// Int8 decodes the next json number as a int8.
+func (d *Decoder) Int8(v *int8) error {
+	return d.readInt(8, func(n int64) {
+		*v = int8(n)
+	})
+}
+
+// Int16 decodes the next json number as a int16.
+func (d *Decoder) Int16(v *int16) error {
+	return d.readInt(16, func(n int64) {
+		*v = int16(n)
+	})
+}
+
+// Int32 decodes the next json number as a int32.
+func (d *Decoder) Int32(v *int32) error {
+	return d.readInt(32, func(n int64) {
+		*v = int32(n)
+	})
+}
+
+// Int64 decodes the next json number as a int64.
+func (d *Decoder) Int64(v *int64) error {
+	return d.readInt(64, func(n int64) {
+		*v = n
+	})
+}
+
+// UInt decodes the next json number as a uint.
+func (d *Decoder) UInt(v *uint) error {
+	return d.readUInt(strconv.IntSize, func(n uint64) {
+		*v = uint(n)
+	})
+}
+
+// UInt8 decodes the next json number as a uint8.
+func (d *Decoder) UInt8(v *uint8) error {
+	return d.readUInt(8, func(n uint64) {
+		*v = uint8(n)
+	})
+}
+
+// UInt16 decodes the next json number as a uint16.
+func (d *Decoder) UInt16(v *uint16) error {
+	return d.readUInt(16, func(n uint64) {
+		*v = uint16(n)
+	})
+}
+
+// UInt32 decodes the next json number as a uint32.
+func (d *Decoder) UInt32(v *uint32) error {
+	return d.readUInt(32, func(n uint64) {
+		*v = uint32(n)
+	})
+}
+
+// UInt64 decodes the next json number as a uint64.
+func (d *Decoder) UInt64(v *uint64) error {
+	return d.readUInt(64, func(n uint64) {
+		*v = n
+	})
+}
+
+// Float64 decodes the next json number as a float64.
+func (d *Decoder) Float64(v *float64) error {
+	return d.readFloat(64, func(n float64) {
+		*v = n
+	})
+}
+
+// Float32 decodes the next json number as a float32.
+func (d *Decoder) Float32(v *float32) error {
+	return d.readFloat(32, func(n float64) {
+		*v = float32(n)
+	})
+}
+
+// Base64 decodes the next value as a int64 formatted in base bs, as written
+// by JSON.Base64. The value may be quoted or unquoted.
+func (d *Decoder) Base64(v *int64, bs int) error {
+	c, err := d.peek()
+	if err != nil {
+		return err
+	}
+	if c == 'n' {
+		return d.readNull()
+	}
+
+	var token []byte
+	if c == '"' {
+		token, err = d.readString()
+	} else {
+		token, err = d.readToken()
+	}
+	if err != nil {
+		return err
+	}
+
+	n, err := strconv.ParseInt(bytes2String(token), bs, 64)
+	if err != nil {
+		return d.syntaxErr("invalid base %d number %q", bs, token)
+	}
+	*v = n
+	return nil
+}
+
+//*****************************************************
+// internal methods
+//*****************************************************
+
+func (d *Decoder) readInt(bitSize int, set func(int64)) error {
+	token, isNull, err := d.readNumber()
+	if err != nil || isNull {
+		return err
+	}
+
+	n, err := strconv.ParseInt(bytes2String(token), 10, bitSize)
+	if err != nil {
+		return d.syntaxErr("invalid %d bit integer %q", bitSize, token)
+	}
+	set(n)
+	return nil
+}
+
+func (d *Decoder) readUInt(bitSize int, set func(uint64)) error {
+	token, isNull, err := d.readNumber()
+	if err != nil || isNull {
+		return err
+	}
+
+	n, err := strconv.ParseUint(bytes2String(token), 10, bitSize)
+	if err != nil {
+		return d.syntaxErr("invalid %d bit unsigned integer %q", bitSize, token)
+	}
+	set(n)
+	return nil
+}
+
+func (d *Decoder) readFloat(bitSize int, set func(float64)) error {
+	token, isNull, err := d.readNumber()
+	if err != nil || isNull {
+		return err
+	}
+
+	n, err := strconv.ParseFloat(bytes2String(token), bitSize)
+	if err != nil {
+		return d.syntaxErr("invalid %d bit float %q", bitSize, token)
+	}
+	set(n)
+	return nil
+}
+
+// readNumber reads the next number token, returning true if
+// the value was a null instead.
+func (d *Decoder) readNumber() ([]byte, bool, error) {
+	c, err := d.peek()
+	if err != nil {
+		return nil, false, err
+	}
+	if c == 'n' {
+		return nil, true, d.readNull()
+	}
+	if c != '-' && (c < '0' || c > '9') {
+		return nil, false, d.syntaxErr("expected number but found %q", c)
+	}
+
+	token, err := d.readToken()
+	return token, false, err
+}
+
+// readToken reads all bytes till the next delimiter, the returned
+// slice is only valid till the next read.
+func (d *Decoder) readToken() ([]byte, error) {
+	var end = 0
+	for {
+		if !d.ensure(end + 1) {
+			break
+		}
+		if isDelimiter(d.buf[d.pos+end]) {
+			break
+		}
+		end++
+	}
+
+	if end == 0 {
+		return nil, d.syntaxErr("expected value")
+	}
+
+	var token = d.buf[d.pos : d.pos+end]
+	d.pos += end
+	return token, nil
+}
+
+func (d *Decoder) readNull() error {
+	return d.readLiteral(nullLiteral)
+}
+
+func (d *Decoder) readLiteral(literal []byte) error {
+	if !d.ensure(len(literal)) {
+		return ErrUnexpectedEOF
+	}
+	for index, c := range literal {
+		if d.buf[d.pos+index] != c {
+			return d.syntaxErr("invalid literal, expected %q", literal)
+		}
+	}
+	if d.ensure(len(literal)+1) && !isDelimiter(d.buf[d.pos+len(literal)]) {
+		return d.syntaxErr("invalid literal, expected %q", literal)
+	}
+	d.pos += len(literal)
+	return nil
+}
+
+// readString reads a quoted string at current position, returning its
+// unescaped content. The returned slice is only valid till the next read.
+func (d *Decoder) readString() ([]byte, error) {
+	// skip opening quote.
+	var off = 1
+	for {
+		if !d.ensure(off + 1) {
+			return nil, ErrUnexpectedEOF
+		}
+
+		var c = d.buf[d.pos+off]
+		if c == '"' {
+			var content = d.buf[d.pos+1 : d.pos+off]
+			d.pos += off + 1
+			return content, nil
+		}
+		if c == '\\' {
+			break
+		}
+		off++
+	}
+
+	// string has escapes, so copy into scratch buffer while unescaping.
+	d.scratch = append(d.scratch[:0], d.buf[d.pos+1:d.pos+off]...)
+	for {
+		if !d.ensure(off + 1) {
+			return nil, ErrUnexpectedEOF
+		}
+
+		var c = d.buf[d.pos+off]
+		switch {
+		case c == '"':
+			d.pos += off + 1
+			return d.scratch, nil
+		case c != '\\':
+			d.scratch = append(d.scratch, c)
+			off++
+			continue
+		}
+
+		if !d.ensure(off + 2) {
+			return nil, ErrUnexpectedEOF
+		}
+
+		var escaped = d.buf[d.pos+off+1]
+		off += 2
+
+		switch escaped {
+		case '"', '\\', '/':
+			d.scratch = append(d.scratch, escaped)
+		case 'b':
+			d.scratch = append(d.scratch, '\b')
+		case 'f':
+			d.scratch = append(d.scratch, '\f')
+		case 'n':
+			d.scratch = append(d.scratch, '\n')
+		case 'r':
+			d.scratch = append(d.scratch, '\r')
+		case 't':
+			d.scratch = append(d.scratch, '\t')
+		case 'u':
+			r, err := d.readRune(off)
+			if err != nil {
+				return nil, err
+			}
+			off += 4
+
+			if utf16.IsSurrogate(r) {
+				var r2 = utf8.RuneError
+				if d.ensure(off+6) && d.buf[d.pos+off] == '\\' && d.buf[d.pos+off+1] == 'u' {
+					if next, err := d.readRune(off + 2); err == nil {
+						if r2 = utf16.DecodeRune(r, next); r2 != utf8.RuneError {
+							off += 6
+						}
+					}
+				}
+				r = r2
+			}
+
+			d.scratch = appendRune(d.scratch, r)
+		default:
+			return nil, d.syntaxErr("invalid escape character %q in string", escaped)
+		}
+	}
+}
+
+// readRune reads the 4 hex digits found at giving offset.
+func (d *Decoder) readRune(off int) (rune, error) {
+	if !d.ensure(off + 4) {
+		return 0, ErrUnexpectedEOF
+	}
+
+	var r rune
+	for _, c := range d.buf[d.pos+off : d.pos+off+4] {
+		switch {
+		case c >= '0' && c <= '9':
+			c = c - '0'
+		case c >= 'a' && c <= 'f':
+			c = c - 'a' + 10
+		case c >= 'A' && c <= 'F':
+			c = c - 'A' + 10
+		default:
+			return 0, d.syntaxErr("invalid unicode escape in string")
+		}
+		r = r*16 + rune(c)
+	}
+	return r, nil
+}
+
+// skipValue skips the next json value.
+func (d *Decoder) skipValue() error {
+	if _, err := d.peek(); err != nil {
+		return err
+	}
+
+	end, err := d.scanValue(0)
+	if err != nil {
+		return err
+	}
+	d.pos += end
+	return nil
+}
+
+// countItems returns the total items of the list found at current
+// position without consuming it.
+func (d *Decoder) countItems() (int64, error) {
+	var total int64
+	var off = d.scanSpace(1)
+	if !d.ensure(off + 1) {
+		return 0, ErrUnexpectedEOF
+	}
+	if d.buf[d.pos+off] == ']' {
+		return 0, nil
+	}
+
+	for {
+		end, err := d.scanValue(off)
+		if err != nil {
+			return total, err
+		}
+		total++
+
+		off = d.scanSpace(end)
+		if !d.ensure(off + 1) {
+			return total, ErrUnexpectedEOF
+		}
+
+		switch d.buf[d.pos+off] {
+		case ',':
+			off = d.scanSpace(off + 1)
+		case ']':
+			return total, nil
+		default:
+			return total, d.syntaxErr("expected ',' or ']' in list but found %q", d.buf[d.pos+off])
+		}
+	}
+}
+
+// scanValue returns the offset right after the value starting at
+// giving offset from current position, without consuming it.
+func (d *Decoder) scanValue(off int) (int, error) {
+	off = d.scanSpace(off)
+	if !d.ensure(off + 1) {
+		return off, ErrUnexpectedEOF
+	}
+
+	switch d.buf[d.pos+off] {
+	case '"':
+		return d.scanString(off)
+	case '{', '[':
+		var depth int
+		for {
+			if !d.ensure(off + 1) {
+				return off, ErrUnexpectedEOF
+			}
+
+			switch d.buf[d.pos+off] {
+			case '"':
+				end, err := d.scanString(off)
+				if err != nil {
+					return end, err
+				}
+				off = end
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+
+			off++
+			if depth == 0 {
+				return off, nil
+			}
+		}
+	}
+
+	var start = off
+	for d.ensure(off+1) && !isDelimiter(d.buf[d.pos+off]) {
+		off++
+	}
+	if off == start {
+		return off, d.syntaxErr("unexpected character %q", d.buf[d.pos+off])
+	}
+	return off, nil
+}
+
+// scanString returns the offset right after the string starting at
+// giving offset from current position.
+func (d *Decoder) scanString(off int) (int, error) {
+	off++
+	for {
+		if !d.ensure(off + 1) {
+			return off, ErrUnexpectedEOF
+		}
+
+		switch d.buf[d.pos+off] {
+		case '\\':
+			off += 2
+		case '"':
+			return off + 1, nil
+		default:
+			off++
+		}
+	}
+}
+
+// scanSpace returns the offset of the first non-space character from
+// giving offset.
+func (d *Decoder) scanSpace(off int) int {
+	for d.ensure(off+1) && isSpace(d.buf[d.pos+off]) {
+		off++
+	}
+	return off
+}
+
+// peek skips all whitespace, returning the next character
+// without consuming it.
+func (d *Decoder) peek() (byte, error) {
+	d.pos += d.scanSpace(0)
+	if !d.ensure(1) {
+		if d.err != nil && d.err != io.EOF {
+			return 0, d.err
+		}
+		return 0, io.EOF
+	}
+	return d.buf[d.pos], nil
+}
+
+// peekMore works like peek but expects more content, returning
+// ErrUnexpectedEOF if the end of the source is reached.
+func (d *Decoder) peekMore() (byte, error) {
+	c, err := d.peek()
+	if err == io.EOF {
+		return 0, ErrUnexpectedEOF
+	}
+	return c, err
+}
+
+// ensure attempts to have at least n unread bytes available in
+// buffer, reading from the underline reader as needed.
+func (d *Decoder) ensure(n int) bool {
+	for len(d.buf)-d.pos < n {
+		if d.err != nil {
+			return false
+		}
+		d.fill()
+	}
+	return true
+}
+
+func (d *Decoder) fill() {
+	// compact buffer, dropping already consumed bytes.
+	if d.pos > 0 {
+		var remaining = copy(d.buf, d.buf[d.pos:])
+		d.buf = d.buf[:remaining]
+		d.base += int64(d.pos)
+		d.pos = 0
+	}
+
+	if cap(d.buf)-len(d.buf) < minReadSize {
+		var newBuf = make([]byte, len(d.buf), 2*cap(d.buf)+minReadSize)
+		copy(newBuf, d.buf)
+		d.buf = newBuf
+	}
+
+	n, err := d.r.Read(d.buf[len(d.buf):cap(d.buf)])
+	d.buf = d.buf[:len(d.buf)+n]
+	if err != nil {
+		d.err = err
+	}
+}
+
+func (d *Decoder) syntaxErr(message string, v ...interface{}) error {
+	return &SyntaxError{
+		Message: fmt.Sprintf(message, v...),
+		Offset:  d.Offset(),
+	}
+}
+
+func appendRune(b []byte, r rune) []byte {
+	var content [utf8.UTFMax]byte
+	var n = utf8.EncodeRune(content[:], r)
+	return append(b, content[:n]...)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t'
+}
+
+func isDelimiter(c byte) bool {
+	return isSpace(c) || c == ',' || c == ':' || c == '}' || c == ']'
+}
@@ -0,0 +1,199 @@
+package njson_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influx6/npkg"
+	"github.com/influx6/npkg/njson"
+)
+
+type address struct {
+	City string
+	Zip  int
+}
+
+func (a *address) EncodeObject(enc npkg.ObjectEncoder) {
+	enc.String("city", a.City)
+	enc.Int("zip", a.Zip)
+}
+
+func (a *address) DecodeKey(dec npkg.Decoder, k string) error {
+	switch k {
+	case "city":
+		return dec.String(&a.City)
+	case "zip":
+		return dec.Int(&a.Zip)
+	}
+	return nil
+}
+
+type addresses []*address
+
+func (a addresses) EncodeList(enc npkg.ListEncoder) {
+	for _, item := range a {
+		enc.AddObject(item)
+	}
+}
+
+func (a *addresses) DecodeIndex(dec npkg.Decoder, index int64, total int64) error {
+	if *a == nil {
+		*a = make(addresses, 0, total)
+	}
+	var item address
+	if err := dec.Object(&item); err != nil {
+		return err
+	}
+	*a = append(*a, &item)
+	return nil
+}
+
+type user struct {
+	Name      string
+	Age       int8
+	Active    bool
+	Score     float64
+	Balance   uint64
+	Mask      int64
+	Addresses addresses
+}
+
+func (u *user) EncodeObject(enc npkg.ObjectEncoder) {
+	enc.String("name", u.Name)
+	enc.Int8("age", u.Age)
+	enc.Bool("active", u.Active)
+	enc.Float64("score", u.Score)
+	enc.UInt64("balance", u.Balance)
+	enc.Base64("mask", u.Mask, 16)
+	enc.List("addresses", u.Addresses)
+}
+
+func (u *user) DecodeKey(dec npkg.Decoder, k string) error {
+	switch k {
+	case "name":
+		return dec.String(&u.Name)
+	case "age":
+		return dec.Int8(&u.Age)
+	case "active":
+		return dec.Bool(&u.Active)
+	case "score":
+		return dec.Float64(&u.Score)
+	case "balance":
+		return dec.UInt64(&u.Balance)
+	case "mask":
+		return dec.Base64(&u.Mask, 16)
+	case "addresses":
+		return dec.List(&u.Addresses)
+	}
+	return nil
+}
+
+func TestDecoder(t *testing.T) {
+	t.Run("round trip encoded object", func(t *testing.T) {
+		var original = &user{
+			Name:    "thunder",
+			Age:     32,
+			Active:  true,
+			Score:   20.5,
+			Balance: 3000,
+			Mask:    255,
+			Addresses: addresses{
+				{City: "Lagos", Zip: 100001},
+				{City: "Berlin", Zip: 10115},
+			},
+		}
+
+		var event = njson.JSONB()
+		original.EncodeObject(event)
+		var content = event.Message()
+
+		var decoded user
+		require.NoError(t, njson.DecodeBytes([]byte(content), &decoded))
+		require.Equal(t, original, &decoded)
+	})
+
+	t.Run("read from one byte reader", func(t *testing.T) {
+		var content = `{"name": "thunder", "addresses": [{"city": "Lagos", "zip": 1}, {"city": "Berlin", "zip": 2}]}`
+		var dec = njson.NewDecoder(iotest.OneByteReader(bytes.NewBufferString(content)))
+
+		var decoded user
+		require.NoError(t, dec.Decode(&decoded))
+		require.Equal(t, "thunder", decoded.Name)
+		require.Len(t, decoded.Addresses, 2)
+		require.Equal(t, "Berlin", decoded.Addresses[1].City)
+	})
+
+	t.Run("skips unknown keys", func(t *testing.T) {
+		var content = `{"extra": {"a": [1, "}", {"b": null}]}, "list": [], "name": "thunder", "flag": false}`
+
+		var decoded user
+		require.NoError(t, njson.DecodeBytes([]byte(content), &decoded))
+		require.Equal(t, "thunder", decoded.Name)
+	})
+
+	t.Run("null leaves value untouched", func(t *testing.T) {
+		var decoded = user{Name: "thunder"}
+		require.NoError(t, njson.DecodeBytes([]byte(`{"name": null, "addresses": null}`), &decoded))
+		require.Equal(t, "thunder", decoded.Name)
+		require.Nil(t, decoded.Addresses)
+	})
+
+	t.Run("escaped strings", func(t *testing.T) {
+		var value string
+		require.NoError(t, njson.DecodeBytes([]byte(`"line\n\"quoted\" é 😀"`), &value))
+		require.Equal(t, "line\n\"quoted\" é 😀", value)
+	})
+
+	t.Run("multiple values from stream", func(t *testing.T) {
+		var dec = njson.NewDecoder(bytes.NewBufferString("1 2\n3"))
+
+		var values []int
+		for dec.More() {
+			var value int
+			require.NoError(t, dec.Decode(&value))
+			values = append(values, value)
+		}
+		require.Equal(t, []int{1, 2, 3}, values)
+
+		var value int
+		require.Equal(t, io.EOF, dec.Decode(&value))
+	})
+
+	t.Run("overflow fails", func(t *testing.T) {
+		var value int8
+		var err = njson.DecodeBytes([]byte(`300`), &value)
+		require.Error(t, err)
+		require.IsType(t, &njson.SyntaxError{}, err)
+	})
+
+	t.Run("incomplete object fails", func(t *testing.T) {
+		var decoded user
+		var err = njson.DecodeBytes([]byte(`{"name": "thunder", "age": `), &decoded)
+		require.Equal(t, njson.ErrUnexpectedEOF, err)
+	})
+
+	t.Run("wrong type fails", func(t *testing.T) {
+		var decoded user
+		var err = njson.DecodeBytes([]byte(`{"name": 20}`), &decoded)
+		require.Error(t, err)
+		require.IsType(t, &njson.SyntaxError{}, err)
+	})
+}
+
+func BenchmarkDecoder(b *testing.B) {
+	var content = []byte(`{"name": "thunder", "age": 32, "active": true, "score": 20.5, "addresses": [{"city": "Lagos", "zip": 100001}]}`)
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := b.N; i > 0; i-- {
+		var decoded user
+		if err := njson.DecodeBytes(content, &decoded); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
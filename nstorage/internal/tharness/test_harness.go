@@ -1,6 +1,7 @@
 package tharness
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"strings"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/influx6/npkg/nerror"
 	"github.com/influx6/npkg/nstorage"
 )
 
@@ -204,6 +206,120 @@ func TestByteStore(t *testing.T, store nstorage.ByteStore) {
 	require.Equal(t, "tweeter", bytes2String(val))
 }
 
+func TestTxStore(t *testing.T, store nstorage.ByteStore) {
+	var txStore, ok = store.(nstorage.TxStore)
+	require.True(t, ok, "store must implement nstorage.TxStore")
+
+	require.NoError(t, store.Save("tx-remove", string2Bytes("old")))
+
+	var tx, err = txStore.Begin()
+	require.NoError(t, err)
+	require.NoError(t, tx.Put("tx-1", string2Bytes("one")))
+	require.NoError(t, tx.Put("tx-2", string2Bytes("two")))
+	require.NoError(t, tx.Delete("tx-remove"))
+
+	exist, err := store.Exists("tx-1")
+	require.NoError(t, err)
+	require.False(t, exist, "uncommitted writes must not be visible")
+
+	exist, err = store.Exists("tx-remove")
+	require.NoError(t, err)
+	require.True(t, exist, "uncommitted deletes must not be visible")
+
+	require.NoError(t, tx.Commit())
+	require.True(t, nerror.IsAny(tx.Put("tx-3", string2Bytes("three")), nstorage.ErrTxDone))
+	require.True(t, nerror.IsAny(tx.Commit(), nstorage.ErrTxDone))
+	require.NoError(t, tx.Rollback())
+
+	values, err := store.GetAllKeys("tx-1", "tx-2")
+	require.NoError(t, err)
+	require.Equal(t, "one", bytes2String(values[0]))
+	require.Equal(t, "two", bytes2String(values[1]))
+
+	exist, err = store.Exists("tx-remove")
+	require.NoError(t, err)
+	require.False(t, exist)
+
+	tx, err = txStore.Begin()
+	require.NoError(t, err)
+	require.NoError(t, tx.Put("tx-rolled", string2Bytes("rolled")))
+	require.NoError(t, tx.Delete("tx-1"))
+	require.NoError(t, tx.Rollback())
+	require.True(t, nerror.IsAny(tx.Commit(), nstorage.ErrTxDone))
+
+	exist, err = store.Exists("tx-rolled")
+	require.NoError(t, err)
+	require.False(t, exist)
+
+	val, err := store.Get("tx-1")
+	require.NoError(t, err)
+	require.Equal(t, "one", bytes2String(val))
+}
+
+func TestWatchableStore(t *testing.T, store nstorage.ByteStore) {
+	var watchable, ok = store.(nstorage.WatchableStore)
+	require.True(t, ok, "store must implement nstorage.WatchableStore")
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	var events, err = watchable.Watch(ctx, "watch-")
+	require.NoError(t, err)
+
+	require.NoError(t, store.Save("ignored-1", string2Bytes("ignored")))
+	require.NoError(t, store.Save("watch-1", string2Bytes("one")))
+	require.NoError(t, store.Update("watch-1", string2Bytes("two")))
+	var _, removeErr = store.Remove("watch-1")
+	require.NoError(t, removeErr)
+
+	var event = receiveEvent(t, events)
+	require.Equal(t, nstorage.PutEvent, event.Type)
+	require.Equal(t, "watch-1", event.Key)
+	require.Equal(t, "one", bytes2String(event.Value))
+
+	event = receiveEvent(t, events)
+	require.Equal(t, nstorage.UpdateEvent, event.Type)
+	require.Equal(t, "watch-1", event.Key)
+	require.Equal(t, "two", bytes2String(event.Value))
+
+	event = receiveEvent(t, events)
+	require.Equal(t, nstorage.DeleteEvent, event.Type)
+	require.Equal(t, "watch-1", event.Key)
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestWatchableExpiry(t *testing.T, store nstorage.ExpirableStore) {
+	var watchable, ok = store.(nstorage.WatchableStore)
+	require.True(t, ok, "store must implement nstorage.WatchableStore")
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	var events, err = watchable.Watch(ctx, "watch-ttl")
+	require.NoError(t, err)
+
+	require.NoError(t, store.SaveTTL("watch-ttl", string2Bytes("one"), time.Second))
+
+	var event = receiveEvent(t, events)
+	require.Equal(t, nstorage.PutEvent, event.Type)
+
+	event = receiveEvent(t, events)
+	require.Equal(t, nstorage.ExpireEvent, event.Type)
+	require.Equal(t, "watch-ttl", event.Key)
+}
+
+func receiveEvent(t *testing.T, events <-chan nstorage.Event) nstorage.Event {
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "events channel must not be closed")
+		return event
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timed out waiting for event")
+	}
+	return nstorage.Event{}
+}
+
 func TestExpiryReset(t *testing.T, store nstorage.ExpirableStore) {
 	require.NoError(t, store.SaveTTL("day", string2Bytes("wrecker"), 3*time.Second))
 
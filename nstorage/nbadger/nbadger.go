@@ -12,6 +12,7 @@ import (
 )
 
 var _ nstorage.ExpirableStore = (*BadgerStore)(nil)
+var _ nstorage.TxStore = (*BadgerStore)(nil)
 
 // BadgerStore implements session management, storage and access using Badger as
 // underline store.
@@ -212,6 +213,9 @@ func (rd *BadgerStore) Exists(key string) (bool, error) {
 	var exist bool
 	if err := rd.Db.View(func(txn *badger.Txn) error {
 		item, err := txn.Get(nunsafe.String2Bytes(key))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
 		if err != nil {
 			return nerror.WrapOnly(err)
 		}
@@ -321,6 +325,7 @@ func (rd *BadgerStore) SaveTTL(key string, data []byte, expiration time.Duration
 		var op badger.Entry
 		op.Value = data
 		op.Key = nunsafe.String2Bytes(key)
+		op.UserMeta = saveMeta(rd.Db, op.Key)
 
 		if expiration > 0 {
 			op.WithTTL(expiration)
@@ -395,6 +400,7 @@ func (rd *BadgerStore) ExtendTTL(key string, expiration time.Duration) error {
 		var op badger.Entry
 		op.Value = value
 		op.Key = nunsafe.String2Bytes(key)
+		op.UserMeta = metaTouch
 
 		if expiration > 0 {
 			op.WithTTL(lastTTL + expiration)
@@ -429,6 +435,7 @@ func (rd *BadgerStore) ResetTTL(key string, expiration time.Duration) error {
 		var op badger.Entry
 		op.Value = value
 		op.Key = nunsafe.String2Bytes(key)
+		op.UserMeta = metaTouch
 
 		if expiration > 0 {
 			op.WithTTL(expiration)
@@ -466,6 +473,7 @@ func (rd *BadgerStore) UpdateTTL(key string, data []byte, expiration time.Durati
 		var op badger.Entry
 		op.Value = data
 		op.Key = nunsafe.String2Bytes(key)
+		op.UserMeta = metaUpdate
 
 		if expiration > 0 {
 			op.WithTTL(expiration)
@@ -518,10 +526,91 @@ func (rd *BadgerStore) RemoveKeys(keys ...string) error {
 	return nil
 }
 
+// Begin returns a new transaction backed by a badger read-write transaction.
+//
+// Badger limits the size of a transaction, a Put or Delete which exceeds
+// this returns badger.ErrTxnTooBig, after which the transaction should be
+// committed and a new one started.
+func (rd *BadgerStore) Begin() (nstorage.Tx, error) {
+	return &badgerTx{db: rd.Db, txn: rd.Db.NewTransaction(true)}, nil
+}
+
+type badgerTx struct {
+	db   *badger.DB
+	txn  *badger.Txn
+	done bool
+}
+
+// Put adds giving key and value to the transaction.
+//
+// The value is owned by the transaction till it is committed or rolled back.
+func (tx *badgerTx) Put(key string, data []byte) error {
+	if tx.done {
+		return nstorage.ErrTxDone
+	}
+	var op = badger.NewEntry(nunsafe.String2Bytes(key), data)
+	op.UserMeta = saveMeta(tx.db, op.Key)
+	if err := tx.txn.SetEntry(op); err != nil {
+		return nerror.WrapOnly(err)
+	}
+	return nil
+}
+
+// Delete adds removal of giving key to the transaction.
+func (tx *badgerTx) Delete(key string) error {
+	if tx.done {
+		return nstorage.ErrTxDone
+	}
+	if err := tx.txn.Delete(nunsafe.String2Bytes(key)); err != nil {
+		return nerror.WrapOnly(err)
+	}
+	return nil
+}
+
+// Commit commits underline badger transaction.
+func (tx *badgerTx) Commit() error {
+	if tx.done {
+		return nstorage.ErrTxDone
+	}
+	tx.done = true
+	if err := tx.txn.Commit(); err != nil {
+		return nerror.WrapOnly(err)
+	}
+	return nil
+}
+
+// Rollback discards underline badger transaction.
+func (tx *badgerTx) Rollback() error {
+	if tx.done {
+		return nil
+	}
+	tx.done = true
+	tx.txn.Discard()
+	return nil
+}
+
 // *****************************************************
 // internal methods
 // *****************************************************
 
+// saveMeta returns the user meta for saving giving key, based on
+// the existence of the key.
+//
+// The lookup is done in a separate read transaction to avoid adding the
+// key to the read set of the write, which would make concurrent saves
+// of the same key conflict.
+func saveMeta(db *badger.DB, key []byte) byte {
+	var meta = metaPut
+	_ = db.View(func(txn *badger.Txn) error {
+		var item, err = txn.Get(key)
+		if err == nil && !item.IsDeletedOrExpired() {
+			meta = metaUpdate
+		}
+		return nil
+	})
+	return meta
+}
+
 func copyBytes(bu []byte) []byte {
 	var cu = make([]byte, len(bu))
 	copy(cu, bu)
@@ -96,3 +96,30 @@ func TestBadgerExpiryStoreTT(t *testing.T) {
 
 	tharness.TestExpiryReset(t, store)
 }
+
+func TestBadgerTxStore(t *testing.T) {
+	var ops = badger.DefaultOptions("").WithInMemory(true)
+	var store, err = NewBadgerStore(ops, badger.DefaultIteratorOptions)
+	require.NoError(t, err)
+	require.NotNil(t, store)
+
+	tharness.TestTxStore(t, store)
+}
+
+func TestBadgerWatchableStore(t *testing.T) {
+	var ops = badger.DefaultOptions("").WithInMemory(true)
+	var store, err = NewBadgerStore(ops, badger.DefaultIteratorOptions)
+	require.NoError(t, err)
+	require.NotNil(t, store)
+
+	tharness.TestWatchableStore(t, store)
+}
+
+func TestBadgerWatchableExpiry(t *testing.T) {
+	var ops = badger.DefaultOptions("").WithInMemory(true)
+	var store, err = NewBadgerStore(ops, badger.DefaultIteratorOptions)
+	require.NoError(t, err)
+	require.NotNil(t, store)
+
+	tharness.TestWatchableExpiry(t, store)
+}
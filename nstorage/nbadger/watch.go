@@ -0,0 +1,249 @@
+package nbadger
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v2"
+
+	"github.com/influx6/npkg/nerror"
+	"github.com/influx6/npkg/nstorage"
+	"github.com/influx6/npkg/nunsafe"
+	"github.com/influx6/npkg/nxid"
+)
+
+// user meta values set on entries written by the store, used
+// to identify the kind of change for watchers.
+const (
+	metaPut byte = iota + 1
+	metaUpdate
+	metaTouch
+)
+
+const (
+	watchBuffer       = 100
+	watchMarkerPrefix = "\x00nstorage-watch-"
+	watchReadyTimeout = 5 * time.Second
+)
+
+var _ nstorage.WatchableStore = (*BadgerStore)(nil)
+
+// Watch returns a channel receiving events for all keys starting with
+// giving prefix, using badger's Subscribe.
+//
+// Badger does not notify of expired keys, instead each watch schedules a
+// expire event for keys it sees written with an expiration, which are
+// delivered if the key is not found once its expiration is reached.
+func (rd *BadgerStore) Watch(ctx context.Context, prefix string) (<-chan nstorage.Event, error) {
+	var w = &badgerWatch{
+		db:     rd.Db,
+		ctx:    ctx,
+		prefix: prefix,
+		marker: watchMarkerPrefix + nxid.New().String(),
+		ready:  make(chan struct{}),
+		events: make(chan nstorage.Event, watchBuffer),
+		timers: map[string]*time.Timer{},
+	}
+
+	var subscribeErr = make(chan error, 1)
+	go func() {
+		defer w.close()
+
+		var err = rd.Db.Subscribe(ctx, w.receive, []byte(prefix), []byte(w.marker))
+		if err != nil && err != context.Canceled && err != context.DeadlineExceeded {
+			subscribeErr <- err
+		}
+	}()
+
+	if err := w.waitReady(subscribeErr); err != nil {
+		return nil, nerror.WrapOnly(err)
+	}
+	return w.events, nil
+}
+
+type badgerWatch struct {
+	db     *badger.DB
+	ctx    context.Context
+	prefix string
+	marker string
+	ready  chan struct{}
+	events chan nstorage.Event
+
+	lock   sync.Mutex
+	closed bool
+	timers map[string]*time.Timer
+}
+
+// waitReady waits till the subscription is registered, as badger registers
+// subscribers asynchronously. It repeatedly writes the watch's marker key
+// till the subscription receives it.
+func (w *badgerWatch) waitReady(subscribeErr chan error) error {
+	var timeout = time.NewTimer(watchReadyTimeout)
+	defer timeout.Stop()
+
+	var ticker = time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	defer func() {
+		_ = w.db.Update(func(txn *badger.Txn) error {
+			return txn.Delete(nunsafe.String2Bytes(w.marker))
+		})
+	}()
+
+	for {
+		if err := w.db.Update(func(txn *badger.Txn) error {
+			return txn.Set(nunsafe.String2Bytes(w.marker), nil)
+		}); err != nil {
+			return err
+		}
+
+		select {
+		case <-w.ready:
+			return nil
+		case err := <-subscribeErr:
+			return err
+		case <-w.ctx.Done():
+			return w.ctx.Err()
+		case <-timeout.C:
+			return nerror.New("timed out waiting for badger subscription")
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *badgerWatch) receive(kvs *badger.KVList) error {
+	for _, kv := range kvs.Kv {
+		var key = string(kv.Key)
+		if key == w.marker {
+			select {
+			case <-w.ready:
+			default:
+				close(w.ready)
+			}
+			continue
+		}
+
+		// the marker of another watch may match our prefix.
+		if strings.HasPrefix(key, watchMarkerPrefix) || !strings.HasPrefix(key, w.prefix) {
+			continue
+		}
+
+		// badger publishes the entry's user meta as the kv's meta.
+		var meta byte
+		if len(kv.Meta) != 0 {
+			meta = kv.Meta[0]
+		}
+
+		var event = nstorage.Event{Key: key}
+		switch {
+		case meta == metaTouch:
+			w.scheduleExpiry(key, kv.ExpiresAt, kv.Version)
+			continue
+		case meta == metaUpdate:
+			event.Type = nstorage.UpdateEvent
+			event.Value = kv.Value
+		case meta == metaPut || len(kv.Value) != 0:
+			event.Type = nstorage.PutEvent
+			event.Value = kv.Value
+		default:
+			event.Type = nstorage.DeleteEvent
+		}
+
+		if event.Type == nstorage.DeleteEvent {
+			w.cancelExpiry(key)
+		} else {
+			w.scheduleExpiry(key, kv.ExpiresAt, kv.Version)
+		}
+
+		w.send(event)
+	}
+	return nil
+}
+
+func (w *badgerWatch) send(event nstorage.Event) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.closed {
+		return
+	}
+
+	select {
+	case w.events <- event:
+	case <-w.ctx.Done():
+	}
+}
+
+// scheduleExpiry schedules a expire event for giving key at giving
+// expiration unix time, replacing any existing one.
+func (w *badgerWatch) scheduleExpiry(key string, expiresAt uint64, version uint64) {
+	w.cancelExpiry(key)
+	if expiresAt == 0 {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.closed {
+		return
+	}
+
+	// badger expires keys once the current unix second is past expiresAt.
+	var wait = time.Until(time.Unix(int64(expiresAt)+1, 0))
+
+	var timer *time.Timer
+	timer = time.AfterFunc(wait, func() {
+		w.lock.Lock()
+		if w.timers[key] != timer {
+			w.lock.Unlock()
+			return
+		}
+		delete(w.timers, key)
+		w.lock.Unlock()
+
+		var expired bool
+		_ = w.db.View(func(txn *badger.Txn) error {
+			var item, err = txn.Get(nunsafe.String2Bytes(key))
+			if err == badger.ErrKeyNotFound {
+				expired = true
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			expired = item.Version() == version && item.IsDeletedOrExpired()
+			return nil
+		})
+
+		if expired {
+			w.send(nstorage.Event{Type: nstorage.ExpireEvent, Key: key})
+		}
+	})
+	w.timers[key] = timer
+}
+
+func (w *badgerWatch) cancelExpiry(key string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if timer, ok := w.timers[key]; ok {
+		timer.Stop()
+		delete(w.timers, key)
+	}
+}
+
+func (w *badgerWatch) close() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for key, timer := range w.timers {
+		timer.Stop()
+		delete(w.timers, key)
+	}
+
+	w.closed = true
+	close(w.events)
+}
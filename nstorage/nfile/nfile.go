@@ -0,0 +1,689 @@
+package nfile
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	regexp2 "regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influx6/npkg/nerror"
+	"github.com/influx6/npkg/nstorage"
+)
+
+var _ nstorage.ExpirableStore = (*FileStore)(nil)
+var _ nstorage.TxStore = (*FileStore)(nil)
+
+const (
+	opPut byte = iota + 1
+	opDelete
+)
+
+// batchFlag is set on the op of all records of a transaction but the last,
+// marking that more records of the same transaction follow.
+const batchFlag byte = 0x80
+
+// headerSize is the size of a record header:
+// crc32 (4) + op (1) + expiresAt (8) + key length (4) + value length (4).
+const headerSize = 4 + 1 + 8 + 4 + 4
+
+const (
+	defaultCompactRatio   = 0.5
+	defaultCompactMinSize = 1 << 20
+)
+
+// Options defines configuration for a FileStore.
+type Options struct {
+	// Path is the path of the log file, it is created if it does not exists.
+	Path string
+
+	// SyncWrites sets the store to sync the file to disk after every write.
+	SyncWrites bool
+
+	// CompactRatio sets the ratio of stale bytes to the total size of the
+	// log above which the log is compacted. Defaults to 0.5.
+	CompactRatio float64
+
+	// CompactMinSize sets the minimum size in bytes the log must reach
+	// before it is compacted. Defaults to 1MB.
+	CompactMinSize int64
+}
+
+type fileEntry struct {
+	value     []byte
+	expiresAt int64
+	size      int64
+}
+
+func (e fileEntry) expired(now int64) bool {
+	return e.expiresAt != 0 && now >= e.expiresAt
+}
+
+func (e fileEntry) ttl(now int64) time.Duration {
+	if e.expiresAt == 0 {
+		return 0
+	}
+	return time.Duration(e.expiresAt - now)
+}
+
+// FileStore implements the nstorage.ExpirableStore using a single append-only
+// log file, with all keys and values held in memory.
+//
+// Every write appends a record to the log, which is replayed on start up. Once
+// the log holds enough stale records, from overwritten, removed or expired keys,
+// it is compacted by writing all live keys into a new log which replaces the old.
+type FileStore struct {
+	ops  Options
+	lock sync.RWMutex
+	file *os.File
+	data map[string]fileEntry
+	size int64
+	dead int64
+}
+
+// NewFileStore returns a new instance of a FileStore, loading all existing
+// records from the log file at the configured path.
+func NewFileStore(ops Options) (*FileStore, error) {
+	if len(ops.Path) == 0 {
+		return nil, nerror.New("Options.Path is required")
+	}
+	if ops.CompactRatio <= 0 {
+		ops.CompactRatio = defaultCompactRatio
+	}
+	if ops.CompactMinSize <= 0 {
+		ops.CompactMinSize = defaultCompactMinSize
+	}
+
+	var fs FileStore
+	fs.ops = ops
+	fs.data = map[string]fileEntry{}
+	if err := fs.load(); err != nil {
+		return nil, err
+	}
+	return &fs, nil
+}
+
+// Close syncs and closes the underline log file.
+func (fs *FileStore) Close() error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if err := fs.file.Sync(); err != nil {
+		return nerror.WrapOnly(err)
+	}
+	if err := fs.file.Close(); err != nil {
+		return nerror.WrapOnly(err)
+	}
+	return nil
+}
+
+// Sync updates to disk.
+func (fs *FileStore) Sync() error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if err := fs.file.Sync(); err != nil {
+		return nerror.WrapOnly(err)
+	}
+	return nil
+}
+
+// Compact rewrites the log with only the live keys of the store.
+func (fs *FileStore) Compact() error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	return fs.compact()
+}
+
+// Count returns the total count of live keys in the store.
+func (fs *FileStore) Count() (int64, error) {
+	fs.lock.RLock()
+	defer fs.lock.RUnlock()
+
+	var count int64
+	var now = time.Now().UnixNano()
+	for _, entry := range fs.data {
+		if entry.expired(now) {
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Keys returns all keys of the store in sorted order.
+func (fs *FileStore) Keys() ([]string, error) {
+	fs.lock.RLock()
+	defer fs.lock.RUnlock()
+	return fs.keys(nil), nil
+}
+
+// Each runs through all keys and values in the store in sorted key order.
+//
+// The value slice is owned by the store and must be copied if
+// it is to be used after the call to the function.
+func (fs *FileStore) Each(fn nstorage.EachItem) error {
+	fs.lock.RLock()
+	var keys = fs.keys(nil)
+	fs.lock.RUnlock()
+
+	for _, key := range keys {
+		fs.lock.RLock()
+		var entry, found = fs.data[key]
+		fs.lock.RUnlock()
+
+		if !found || entry.expired(time.Now().UnixNano()) {
+			continue
+		}
+
+		if err := fn(entry.value, key); err != nil {
+			if nerror.IsAny(err, nstorage.ErrJustStop) {
+				return nil
+			}
+			return nerror.WrapOnly(err)
+		}
+	}
+	return nil
+}
+
+// EachKeyMatch returns all keys matching giving regexp in sorted order.
+func (fs *FileStore) EachKeyMatch(regexp string) ([]string, error) {
+	if len(regexp) == 0 {
+		regexp = ".+"
+	}
+
+	var generatedRegEx, rgErr = regexp2.Compile(regexp)
+	if rgErr != nil {
+		return nil, nerror.WrapOnly(rgErr)
+	}
+
+	fs.lock.RLock()
+	defer fs.lock.RUnlock()
+	return fs.keys(generatedRegEx), nil
+}
+
+// ScanMatch returns count keys matching giving regexp from lastIndex,
+// over the keys sorted in order.
+func (fs *FileStore) ScanMatch(count int64, lastIndex int64, _ string, regexp string) (nstorage.ScanResult, error) {
+	var rs nstorage.ScanResult
+
+	var keys, keyFetchErr = fs.EachKeyMatch(regexp)
+	if keyFetchErr != nil {
+		return rs, nerror.WrapOnly(keyFetchErr)
+	}
+
+	var total = int64(len(keys))
+	if lastIndex > total {
+		lastIndex = total
+	}
+
+	var end = lastIndex + count
+	if end >= total {
+		end = total
+		rs.Finished = true
+	}
+
+	rs.Keys = keys[lastIndex:end]
+	rs.LastIndex = end
+	return rs, nil
+}
+
+// Exists returns true/false if giving key exists.
+func (fs *FileStore) Exists(key string) (bool, error) {
+	fs.lock.RLock()
+	defer fs.lock.RUnlock()
+
+	var _, found = fs.get(key)
+	return found, nil
+}
+
+// Get returns the value of giving key.
+func (fs *FileStore) Get(key string) ([]byte, error) {
+	fs.lock.RLock()
+	defer fs.lock.RUnlock()
+
+	var entry, found = fs.get(key)
+	if !found {
+		return nil, nerror.New("not found")
+	}
+	return copyBytes(entry.value), nil
+}
+
+// GetAnyKeys returns a list of values for any of the key's found, with nil
+// set in place of keys not found.
+func (fs *FileStore) GetAnyKeys(keys ...string) ([][]byte, error) {
+	fs.lock.RLock()
+	defer fs.lock.RUnlock()
+
+	var values = make([][]byte, len(keys))
+	for index, key := range keys {
+		if entry, found := fs.get(key); found {
+			values[index] = copyBytes(entry.value)
+		}
+	}
+	return values, nil
+}
+
+// GetAllKeys returns a list of values for all giving keys, returning an
+// error if any is not found.
+func (fs *FileStore) GetAllKeys(keys ...string) ([][]byte, error) {
+	fs.lock.RLock()
+	defer fs.lock.RUnlock()
+
+	var values = make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		var entry, found = fs.get(key)
+		if !found {
+			return values, nerror.New("not found").Add("key", key)
+		}
+		values = append(values, copyBytes(entry.value))
+	}
+	return values, nil
+}
+
+// TTL returns the remaining time before giving key expires.
+//
+// A zero value means it has no expiration.
+func (fs *FileStore) TTL(key string) (time.Duration, error) {
+	fs.lock.RLock()
+	defer fs.lock.RUnlock()
+
+	var entry, found = fs.get(key)
+	if !found {
+		return 0, nerror.New("not found, possibly expired")
+	}
+	return entry.ttl(time.Now().UnixNano()), nil
+}
+
+// Save adds giving key and value into store without an expiration.
+func (fs *FileStore) Save(key string, data []byte) error {
+	return fs.SaveTTL(key, data, 0)
+}
+
+// SaveTTL adds giving key and value into store with giving expiration.
+//
+// A zero value means no expiration.
+func (fs *FileStore) SaveTTL(key string, data []byte, expiration time.Duration) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	return fs.put(key, data, expiresAt(expiration))
+}
+
+// Update updates the value of an existing key, removing it's expiration.
+func (fs *FileStore) Update(key string, data []byte) error {
+	return fs.UpdateTTL(key, data, 0)
+}
+
+// UpdateTTL updates the value of an existing key with a new expiration.
+//
+// A zero value persists the key.
+func (fs *FileStore) UpdateTTL(key string, data []byte, expiration time.Duration) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if _, found := fs.get(key); !found {
+		return nerror.New("not found, possibly expired")
+	}
+	return fs.put(key, data, expiresAt(expiration))
+}
+
+// ExtendTTL extends the expiration of giving key by provided duration.
+//
+// A zero value persists the key.
+func (fs *FileStore) ExtendTTL(key string, expiration time.Duration) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	var entry, found = fs.get(key)
+	if !found {
+		return nerror.New("not found, possibly expired")
+	}
+
+	var newExpiry int64
+	if expiration > 0 {
+		newExpiry = time.Now().Add(entry.ttl(time.Now().UnixNano()) + expiration).UnixNano()
+	}
+	return fs.put(key, entry.value, newExpiry)
+}
+
+// ResetTTL resets the expiration of giving key to provided duration.
+//
+// A zero value persists the key.
+func (fs *FileStore) ResetTTL(key string, expiration time.Duration) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	var entry, found = fs.get(key)
+	if !found {
+		return nerror.New("not found, possibly expired")
+	}
+	return fs.put(key, entry.value, expiresAt(expiration))
+}
+
+// Remove removes giving key from the store, returning it's value.
+func (fs *FileStore) Remove(key string) ([]byte, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	var entry, found = fs.get(key)
+	if !found {
+		return nil, nerror.New("not found")
+	}
+	if err := fs.delete(key); err != nil {
+		return nil, err
+	}
+	return entry.value, nil
+}
+
+// RemoveKeys removes all giving keys from the store.
+func (fs *FileStore) RemoveKeys(keys ...string) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	for _, key := range keys {
+		if _, found := fs.data[key]; !found {
+			continue
+		}
+		if err := fs.delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// *****************************************************
+// internal methods
+// *****************************************************
+
+func (fs *FileStore) get(key string) (fileEntry, bool) {
+	var entry, found = fs.data[key]
+	if !found || entry.expired(time.Now().UnixNano()) {
+		return fileEntry{}, false
+	}
+	return entry, true
+}
+
+// keys returns the sorted list of live keys matching giving regexp if any.
+func (fs *FileStore) keys(matcher *regexp2.Regexp) []string {
+	var now = time.Now().UnixNano()
+	var keys = make([]string, 0, len(fs.data))
+	for key, entry := range fs.data {
+		if entry.expired(now) {
+			continue
+		}
+		if matcher != nil && !matcher.MatchString(key) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (fs *FileStore) put(key string, data []byte, expiresAt int64) error {
+	var value = copyBytes(data)
+	var record = encodeRecord(nil, opPut, key, value, expiresAt)
+	if err := fs.write(record); err != nil {
+		return err
+	}
+
+	fs.applyPut(key, value, expiresAt, int64(len(record)))
+	return fs.maybeCompact()
+}
+
+func (fs *FileStore) delete(key string) error {
+	var record = encodeRecord(nil, opDelete, key, nil, 0)
+	if err := fs.write(record); err != nil {
+		return err
+	}
+
+	fs.applyDelete(key, int64(len(record)))
+	return fs.maybeCompact()
+}
+
+func (fs *FileStore) applyPut(key string, value []byte, expiresAt int64, size int64) {
+	if old, found := fs.data[key]; found {
+		fs.dead += old.size
+	}
+	fs.data[key] = fileEntry{value: value, expiresAt: expiresAt, size: size}
+}
+
+func (fs *FileStore) applyDelete(key string, size int64) {
+	if old, found := fs.data[key]; found {
+		fs.dead += old.size
+	}
+	fs.dead += size
+	delete(fs.data, key)
+}
+
+// write appends giving encoded records into the log.
+func (fs *FileStore) write(records []byte) error {
+	if _, err := fs.file.Write(records); err != nil {
+		return nerror.WrapOnly(err)
+	}
+	if fs.ops.SyncWrites {
+		if err := fs.file.Sync(); err != nil {
+			return nerror.WrapOnly(err)
+		}
+	}
+
+	fs.size += int64(len(records))
+	return nil
+}
+
+func (fs *FileStore) maybeCompact() error {
+	if fs.size < fs.ops.CompactMinSize {
+		return nil
+	}
+	if float64(fs.dead) < float64(fs.size)*fs.ops.CompactRatio {
+		return nil
+	}
+	return fs.compact()
+}
+
+// compact writes all live keys into a new log file which
+// replaces the current log.
+func (fs *FileStore) compact() error {
+	var compactPath = fs.ops.Path + ".compact"
+	var target, err = os.OpenFile(compactPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return nerror.WrapOnly(err)
+	}
+
+	var writer = bufio.NewWriter(target)
+	var record []byte
+	var size int64
+	var now = time.Now().UnixNano()
+	var data = make(map[string]fileEntry, len(fs.data))
+	for key, entry := range fs.data {
+		if entry.expired(now) {
+			continue
+		}
+
+		record = encodeRecord(record[:0], opPut, key, entry.value, entry.expiresAt)
+		if _, err := writer.Write(record); err != nil {
+			_ = target.Close()
+			return nerror.WrapOnly(err)
+		}
+
+		entry.size = int64(len(record))
+		size += entry.size
+		data[key] = entry
+	}
+
+	if err := writer.Flush(); err != nil {
+		_ = target.Close()
+		return nerror.WrapOnly(err)
+	}
+	if err := target.Sync(); err != nil {
+		_ = target.Close()
+		return nerror.WrapOnly(err)
+	}
+	if err := target.Close(); err != nil {
+		return nerror.WrapOnly(err)
+	}
+
+	if err := os.Rename(compactPath, fs.ops.Path); err != nil {
+		return nerror.WrapOnly(err)
+	}
+
+	var file, openErr = os.OpenFile(fs.ops.Path, os.O_RDWR|os.O_APPEND, 0644)
+	if openErr != nil {
+		return nerror.WrapOnly(openErr)
+	}
+
+	_ = fs.file.Close()
+	fs.file = file
+	fs.data = data
+	fs.size = size
+	fs.dead = 0
+	return nil
+}
+
+// load replays all records of the log file, truncating the log after the
+// last complete record or transaction, as a interrupted write may leave an
+// incomplete or corrupted tail.
+func (fs *FileStore) load() error {
+	var file, err = os.OpenFile(fs.ops.Path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nerror.WrapOnly(err)
+	}
+
+	var stat, statErr = file.Stat()
+	if statErr != nil {
+		_ = file.Close()
+		return nerror.WrapOnly(statErr)
+	}
+
+	var offset, read int64
+	var pending []logRecord
+	var reader = bufio.NewReader(file)
+	for {
+		var record, readErr = readRecord(reader, stat.Size()-read)
+		if readErr == io.EOF || readErr == errCorrupted {
+			break
+		}
+		if readErr != nil {
+			_ = file.Close()
+			return nerror.WrapOnly(readErr)
+		}
+
+		read += record.size
+		pending = append(pending, record)
+		if record.op&batchFlag != 0 {
+			continue
+		}
+
+		for _, pr := range pending {
+			if pr.op&^batchFlag == opDelete {
+				fs.applyDelete(pr.key, pr.size)
+			} else {
+				fs.applyPut(pr.key, pr.value, pr.expiresAt, pr.size)
+			}
+			offset += pr.size
+		}
+		pending = pending[:0]
+	}
+
+	if err := file.Truncate(offset); err != nil {
+		_ = file.Close()
+		return nerror.WrapOnly(err)
+	}
+
+	fs.file = file
+	fs.size = offset
+	return nil
+}
+
+var errCorrupted = nerror.New("corrupted record")
+
+type logRecord struct {
+	op        byte
+	key       string
+	value     []byte
+	expiresAt int64
+	size      int64
+}
+
+// readRecord reads the next record from giving reader with remaining bytes
+// left, returning io.EOF at the end of the log and errCorrupted for a
+// incomplete or corrupted record.
+func readRecord(reader io.Reader, remaining int64) (logRecord, error) {
+	var record logRecord
+
+	var header [headerSize]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return record, errCorrupted
+		}
+		return record, err
+	}
+
+	var checksum = binary.BigEndian.Uint32(header[0:4])
+	var keyLen = int(binary.BigEndian.Uint32(header[13:17]))
+	var valueLen = int(binary.BigEndian.Uint32(header[17:21]))
+	if int64(headerSize+keyLen+valueLen) > remaining {
+		return record, errCorrupted
+	}
+
+	var body = make([]byte, keyLen+valueLen)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return record, errCorrupted
+		}
+		return record, err
+	}
+
+	var crc = crc32.NewIEEE()
+	_, _ = crc.Write(header[4:])
+	_, _ = crc.Write(body)
+	if crc.Sum32() != checksum {
+		return record, errCorrupted
+	}
+
+	record.op = header[4]
+	if op := record.op &^ batchFlag; op != opPut && op != opDelete {
+		return record, errCorrupted
+	}
+
+	record.key = string(body[:keyLen])
+	record.value = body[keyLen:]
+	record.expiresAt = int64(binary.BigEndian.Uint64(header[5:13]))
+	record.size = int64(headerSize + len(body))
+	return record, nil
+}
+
+// encodeRecord appends the encoded record into giving slice.
+func encodeRecord(b []byte, op byte, key string, value []byte, expiresAt int64) []byte {
+	var start = len(b)
+	var header [headerSize]byte
+	header[4] = op
+	binary.BigEndian.PutUint64(header[5:13], uint64(expiresAt))
+	binary.BigEndian.PutUint32(header[13:17], uint32(len(key)))
+	binary.BigEndian.PutUint32(header[17:21], uint32(len(value)))
+
+	b = append(b, header[:]...)
+	b = append(b, key...)
+	b = append(b, value...)
+
+	binary.BigEndian.PutUint32(b[start:start+4], crc32.ChecksumIEEE(b[start+4:]))
+	return b
+}
+
+func expiresAt(expiration time.Duration) int64 {
+	if expiration <= 0 {
+		return 0
+	}
+	return time.Now().Add(expiration).UnixNano()
+}
+
+func copyBytes(bu []byte) []byte {
+	var cu = make([]byte, len(bu))
+	copy(cu, bu)
+	return cu
+}
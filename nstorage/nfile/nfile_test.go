@@ -0,0 +1,209 @@
+package nfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influx6/npkg/nstorage/internal/tharness"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreWithFileStoreRemoveKeys(t *testing.T) {
+	var store, err = NewFileStore(Options{Path: filepath.Join(t.TempDir(), "store.log")})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestByteStoreRemoveKeys(t, store)
+}
+
+func TestStoreWithFileStoreScanMatch(t *testing.T) {
+	var store, err = NewFileStore(Options{Path: filepath.Join(t.TempDir(), "store.log")})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestByteStoreScanMatch(t, store)
+}
+
+func TestStoreWithFileStoreGetAnykeys(t *testing.T) {
+	var store, err = NewFileStore(Options{Path: filepath.Join(t.TempDir(), "store.log")})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestByteStoreGetAnykeys(t, store)
+}
+
+func TestStoreWithFileStoreGetAllkeys(t *testing.T) {
+	var store, err = NewFileStore(Options{Path: filepath.Join(t.TempDir(), "store.log")})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestByteStoreGetAllkeys(t, store)
+}
+
+func TestStoreWithFileStoreFindEach(t *testing.T) {
+	var store, err = NewFileStore(Options{Path: filepath.Join(t.TempDir(), "store.log")})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestByteStoreFindEach(t, store)
+}
+
+func TestStoreWithFileStoreFindPrefix(t *testing.T) {
+	var store, err = NewFileStore(Options{Path: filepath.Join(t.TempDir(), "store.log")})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestByteStoreFindPrefix(t, store)
+}
+
+func TestStoreWithFileStoreFindAll(t *testing.T) {
+	var store, err = NewFileStore(Options{Path: filepath.Join(t.TempDir(), "store.log")})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestByteStoreFindAll(t, store)
+}
+
+func TestStoreWithFileStore(t *testing.T) {
+	var store, err = NewFileStore(Options{Path: filepath.Join(t.TempDir(), "store.log")})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestByteStore(t, store)
+}
+
+func TestFileExpiryStore(t *testing.T) {
+	var store, err = NewFileStore(Options{Path: filepath.Join(t.TempDir(), "store.log")})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestExpirableStore(t, store)
+}
+
+func TestFileExpiryStoreReset(t *testing.T) {
+	var store, err = NewFileStore(Options{Path: filepath.Join(t.TempDir(), "store.log")})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestExpiryReset(t, store)
+}
+
+func TestFileTxStore(t *testing.T) {
+	var store, err = NewFileStore(Options{Path: filepath.Join(t.TempDir(), "store.log")})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestTxStore(t, store)
+}
+
+func TestFileStoreReopen(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "store.log")
+	var store, err = NewFileStore(Options{Path: path})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Save("day", []byte("wrecker")))
+	require.NoError(t, store.SaveTTL("night", []byte("sleeper"), time.Hour))
+	require.NoError(t, store.SaveTTL("gone", []byte("soon"), time.Millisecond))
+	require.NoError(t, store.Save("removed", []byte("bye")))
+	require.NoError(t, store.Update("day", []byte("tweeter")))
+	var _, removeErr = store.Remove("removed")
+	require.NoError(t, removeErr)
+	require.NoError(t, store.Close())
+
+	time.Sleep(5 * time.Millisecond)
+
+	store, err = NewFileStore(Options{Path: path})
+	require.NoError(t, err)
+	defer store.Close()
+
+	keys, err := store.Keys()
+	require.NoError(t, err)
+	require.Equal(t, []string{"day", "night"}, keys)
+
+	val, err := store.Get("day")
+	require.NoError(t, err)
+	require.Equal(t, "tweeter", string(val))
+
+	ttl, err := store.TTL("night")
+	require.NoError(t, err)
+	require.True(t, ttl > 59*time.Minute)
+}
+
+func TestFileStoreCompact(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "store.log")
+	var store, err = NewFileStore(Options{Path: path, CompactMinSize: 1024})
+	require.NoError(t, err)
+
+	for i := 0; i < 200; i++ {
+		require.NoError(t, store.Save("day", []byte("wrecker")))
+	}
+	require.NoError(t, store.Save("night", []byte("sleeper")))
+
+	var stat, statErr = os.Stat(path)
+	require.NoError(t, statErr)
+	require.True(t, stat.Size() < 1024, "log should have been compacted")
+
+	require.NoError(t, store.Compact())
+	require.NoError(t, store.Close())
+
+	store, err = NewFileStore(Options{Path: path})
+	require.NoError(t, err)
+	defer store.Close()
+
+	values, err := store.GetAllKeys("day", "night")
+	require.NoError(t, err)
+	require.Equal(t, "wrecker", string(values[0]))
+	require.Equal(t, "sleeper", string(values[1]))
+}
+
+func TestFileStoreDiscardsIncompleteTail(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "store.log")
+	var store, err = NewFileStore(Options{Path: path})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Save("day", []byte("wrecker")))
+
+	var tx, txErr = store.Begin()
+	require.NoError(t, txErr)
+	require.NoError(t, tx.Put("night", []byte("sleeper")))
+	require.NoError(t, tx.Put("noon", []byte("eater")))
+	require.NoError(t, tx.Commit())
+	require.NoError(t, store.Close())
+
+	// cut the log within the last record of the transaction.
+	var stat, statErr = os.Stat(path)
+	require.NoError(t, statErr)
+	require.NoError(t, os.Truncate(path, stat.Size()-2))
+
+	store, err = NewFileStore(Options{Path: path})
+	require.NoError(t, err)
+
+	keys, err := store.Keys()
+	require.NoError(t, err)
+	require.Equal(t, []string{"day"}, keys)
+
+	// new writes must follow the last complete record.
+	require.NoError(t, store.Save("noon", []byte("eater")))
+	require.NoError(t, store.Close())
+
+	store, err = NewFileStore(Options{Path: path})
+	require.NoError(t, err)
+	defer store.Close()
+
+	keys, err = store.Keys()
+	require.NoError(t, err)
+	require.Equal(t, []string{"day", "noon"}, keys)
+}
@@ -0,0 +1,113 @@
+package nfile
+
+import (
+	"github.com/influx6/npkg/nstorage"
+)
+
+// Begin returns a new transaction which writes all it's operations
+// as a single batch of records into the log on commit.
+//
+// A batch interrupted by a crash is discarded entirely on load.
+func (fs *FileStore) Begin() (nstorage.Tx, error) {
+	return &fileTx{store: fs}, nil
+}
+
+type fileTxOp struct {
+	key    string
+	value  []byte
+	delete bool
+}
+
+type fileTx struct {
+	store *FileStore
+	ops   []fileTxOp
+	done  bool
+}
+
+// Put buffers the saving of giving key and value.
+func (tx *fileTx) Put(k string, v []byte) error {
+	if tx.done {
+		return nstorage.ErrTxDone
+	}
+	tx.ops = append(tx.ops, fileTxOp{key: k, value: copyBytes(v)})
+	return nil
+}
+
+// Delete buffers the removal of giving key.
+func (tx *fileTx) Delete(k string) error {
+	if tx.done {
+		return nstorage.ErrTxDone
+	}
+	tx.ops = append(tx.ops, fileTxOp{key: k, delete: true})
+	return nil
+}
+
+// Commit writes all buffered operations into the log at once.
+func (tx *fileTx) Commit() error {
+	if tx.done {
+		return nstorage.ErrTxDone
+	}
+	tx.done = true
+
+	var fs = tx.store
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	// deletes of missing keys are dropped, as load has no
+	// entry to account them against.
+	var ops = make([]fileTxOp, 0, len(tx.ops))
+	var exists = map[string]bool{}
+	for _, op := range tx.ops {
+		var found, seen = exists[op.key]
+		if !seen {
+			_, found = fs.get(op.key)
+		}
+		if op.delete && !found {
+			continue
+		}
+		exists[op.key] = !op.delete
+		ops = append(ops, op)
+	}
+	tx.ops = nil
+
+	if len(ops) == 0 {
+		return nil
+	}
+
+	var records []byte
+	var sizes = make([]int64, len(ops))
+	for index, op := range ops {
+		var start = len(records)
+
+		var code = opPut
+		if op.delete {
+			code = opDelete
+		}
+		if index < len(ops)-1 {
+			code |= batchFlag
+		}
+
+		records = encodeRecord(records, code, op.key, op.value, 0)
+		sizes[index] = int64(len(records) - start)
+	}
+
+	if err := fs.write(records); err != nil {
+		return err
+	}
+
+	for index, op := range ops {
+		if op.delete {
+			fs.applyDelete(op.key, sizes[index])
+			continue
+		}
+		fs.applyPut(op.key, op.value, 0, sizes[index])
+	}
+	return fs.maybeCompact()
+}
+
+// Rollback discards all buffered operations.
+func (tx *fileTx) Rollback() error {
+	tx.done = true
+	tx.ops = nil
+	return nil
+}
@@ -9,11 +9,13 @@ import (
 )
 
 var _ nstorage.ExpirableStore = (*ExprByteStore)(nil)
+var _ nstorage.TxStore = (*ExprByteStore)(nil)
 
 // ExprByteStore implements an expiring byte store that
 // matches the nstorage.ExpirableStorage interface.
 type ExprByteStore struct {
 	cache *ExpiringByteMap
+	hub   exprHub
 }
 
 // NewExprByteStore returns a new instance of a ExprByteStore.
@@ -72,7 +74,7 @@ func (expr *ExprByteStore) Exists(k string) (bool, error) {
 // Save adds giving key and value into store.
 func (expr *ExprByteStore) Save(k string, v []byte) error {
 	var cm = append(make([]byte, 0, len(v)), v...)
-	expr.cache.Set(k, cm, 0)
+	expr.set(k, cm, 0)
 	return nil
 }
 
@@ -82,6 +84,7 @@ func (expr *ExprByteStore) Save(k string, v []byte) error {
 // A expiration value of zero means to persist the giving key.
 func (expr *ExprByteStore) ExtendTTL(k string, t time.Duration) error {
 	expr.cache.ExtendTTL(k, t)
+	expr.scheduleExpiry(k)
 	return nil
 }
 
@@ -91,20 +94,21 @@ func (expr *ExprByteStore) ExtendTTL(k string, t time.Duration) error {
 // A expiration value of zero means to persist the giving key.
 func (expr *ExprByteStore) ResetTTL(k string, t time.Duration) error {
 	expr.cache.ResetTTL(k, t)
+	expr.scheduleExpiry(k)
 	return nil
 }
 
 // Updates updates giving key and value into store.
 func (expr *ExprByteStore) Update(k string, v []byte) error {
 	var cm = append(make([]byte, 0, len(v)), v...)
-	expr.cache.Set(k, cm, 0)
+	expr.set(k, cm, 0)
 	return nil
 }
 
 // SaveTTL updates giving key and value into store with expiration value.
 func (expr *ExprByteStore) SaveTTL(k string, v []byte, t time.Duration) error {
 	var cm = append(make([]byte, 0, len(v)), v...)
-	expr.cache.Set(k, cm, t)
+	expr.set(k, cm, t)
 	return nil
 }
 
@@ -115,7 +119,7 @@ func (expr *ExprByteStore) UpdateTTL(k string, v []byte, t time.Duration) error
 	}
 
 	var cm = append(make([]byte, 0, len(v)), v...)
-	expr.cache.Set(k, cm, t)
+	expr.set(k, cm, t)
 	return nil
 }
 
@@ -191,23 +195,39 @@ func (expr *ExprByteStore) EachKeyMatch(regexp string) ([]string, error) {
 
 // RemoveKeys deletes giving key from underling store.
 func (expr *ExprByteStore) RemoveKeys(ks ...string) error {
-	expr.cache.GetMany(func(values map[string]ExpiringValue) {
+	var removed = make([]string, 0, len(ks))
+	expr.cache.SetMany(func(values map[string]ExpiringValue) {
 		for _, key := range ks {
+			if _, hasKey := values[key]; !hasKey {
+				continue
+			}
 			delete(values, key)
+			removed = append(removed, key)
 		}
 	})
+
+	for _, key := range removed {
+		expr.hub.cancelExpiry(key)
+		expr.publish(nstorage.DeleteEvent, key, nil)
+	}
 	return nil
 }
 
 func (expr *ExprByteStore) Clear() {
+	var keys, _ = expr.Keys()
 	expr.cache.Reset()
+
+	for _, key := range keys {
+		expr.hub.cancelExpiry(key)
+		expr.publish(nstorage.DeleteEvent, key, nil)
+	}
 }
 
 // Remove deletes giving key from underling store.
 func (expr *ExprByteStore) Remove(k string) ([]byte, error) {
 	var v []byte
 	var found bool
-	expr.cache.GetMany(func(values map[string]ExpiringValue) {
+	expr.cache.SetMany(func(values map[string]ExpiringValue) {
 		var value, hasKey = values[k]
 		if !hasKey {
 			return
@@ -222,5 +242,115 @@ func (expr *ExprByteStore) Remove(k string) ([]byte, error) {
 	if !found {
 		return nil, nerror.New("Key does not exists")
 	}
+
+	expr.hub.cancelExpiry(k)
+	expr.publish(nstorage.DeleteEvent, k, nil)
 	return v, nil
 }
+
+// set adds giving key and value into underline cache, publishing a put or
+// update event based on the existence of the key.
+//
+// if expiration is zero then the key's existing expiration is left as is.
+func (expr *ExprByteStore) set(k string, v []byte, t time.Duration) {
+	var existed bool
+	expr.cache.SetMany(func(values map[string]ExpiringValue) {
+		if nval, ok := values[k]; ok {
+			existed = true
+			nval.Value = v
+			if t > 0 {
+				nval.when = time.Now().Add(t)
+			}
+			values[k] = nval
+			return
+		}
+		values[k] = NewExpiringValue(v, t)
+	})
+
+	if existed {
+		expr.publish(nstorage.UpdateEvent, k, v)
+	} else {
+		expr.publish(nstorage.PutEvent, k, v)
+	}
+	expr.scheduleExpiry(k)
+}
+
+// Begin returns a new transaction which buffers all operations, applying
+// them on commit to a copy of the underline map which then replaces it.
+func (expr *ExprByteStore) Begin() (nstorage.Tx, error) {
+	return &exprTx{store: expr}, nil
+}
+
+type exprTxOp struct {
+	key    string
+	value  []byte
+	delete bool
+}
+
+type exprTx struct {
+	store *ExprByteStore
+	ops   []exprTxOp
+	done  bool
+}
+
+// Put buffers the saving of giving key and value.
+func (tx *exprTx) Put(k string, v []byte) error {
+	if tx.done {
+		return nstorage.ErrTxDone
+	}
+	var cm = append(make([]byte, 0, len(v)), v...)
+	tx.ops = append(tx.ops, exprTxOp{key: k, value: cm})
+	return nil
+}
+
+// Delete buffers the removal of giving key.
+func (tx *exprTx) Delete(k string) error {
+	if tx.done {
+		return nstorage.ErrTxDone
+	}
+	tx.ops = append(tx.ops, exprTxOp{key: k, delete: true})
+	return nil
+}
+
+// Commit applies all buffered operations at once.
+func (tx *exprTx) Commit() error {
+	if tx.done {
+		return nstorage.ErrTxDone
+	}
+	tx.done = true
+
+	var events = make([]nstorage.Event, 0, len(tx.ops))
+	tx.store.cache.SetMany(func(values map[string]ExpiringValue) {
+		for _, op := range tx.ops {
+			var _, existed = values[op.key]
+			if op.delete {
+				if existed {
+					delete(values, op.key)
+					events = append(events, nstorage.Event{Type: nstorage.DeleteEvent, Key: op.key})
+				}
+				continue
+			}
+
+			values[op.key] = NewExpiringValue(op.value, 0)
+			if existed {
+				events = append(events, nstorage.Event{Type: nstorage.UpdateEvent, Key: op.key, Value: op.value})
+			} else {
+				events = append(events, nstorage.Event{Type: nstorage.PutEvent, Key: op.key, Value: op.value})
+			}
+		}
+	})
+	tx.ops = nil
+
+	for _, event := range events {
+		tx.store.hub.cancelExpiry(event.Key)
+		tx.store.hub.publish(event)
+	}
+	return nil
+}
+
+// Rollback discards all buffered operations.
+func (tx *exprTx) Rollback() error {
+	tx.done = true
+	tx.ops = nil
+	return nil
+}
@@ -69,3 +69,24 @@ func TestNMapExpiryStore(t *testing.T) {
 
 	tharness.TestExpirableStore(t, store)
 }
+
+func TestNMapTxStore(t *testing.T) {
+	var store = NewExprByteStore(100)
+	require.NotNil(t, store)
+
+	tharness.TestTxStore(t, store)
+}
+
+func TestNMapWatchableStore(t *testing.T) {
+	var store = NewExprByteStore(100)
+	require.NotNil(t, store)
+
+	tharness.TestWatchableStore(t, store)
+}
+
+func TestNMapWatchableExpiry(t *testing.T) {
+	var store = NewExprByteStore(100)
+	require.NotNil(t, store)
+
+	tharness.TestWatchableExpiry(t, store)
+}
@@ -647,16 +647,20 @@ func (m *ExpiringByteMap) Reset() {
 }
 
 // SetMany adds giving key into underline map.
+//
+// All changes made by fn are made visible at once, with concurrent
+// calls to SetMany applied one after the other.
 func (m *ExpiringByteMap) SetMany(fn func(map[string]ExpiringValue)) {
 	m.init()
 
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	var cached = m.cache.Load().(map[string]ExpiringValue)
 	var copied = CopyExpiringBytesMap(cached)
 	fn(copied)
 
-	m.lock.Lock()
 	m.cache.Store(copied)
-	m.lock.Unlock()
 }
 
 func (m *ExpiringByteMap) init() {
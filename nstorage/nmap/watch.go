@@ -0,0 +1,165 @@
+package nmap
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influx6/npkg/nstorage"
+)
+
+const watchBuffer = 100
+
+var _ nstorage.WatchableStore = (*ExprByteStore)(nil)
+
+// Watch returns a channel receiving events for all keys starting with
+// giving prefix.
+//
+// Events are delivered in-process by the store, a slow receiver will
+// block writers to the store till it's events are received or the
+// context is cancelled.
+func (expr *ExprByteStore) Watch(ctx context.Context, prefix string) (<-chan nstorage.Event, error) {
+	return expr.hub.watch(ctx, prefix), nil
+}
+
+// publish sends giving event to all watchers of giving key.
+func (expr *ExprByteStore) publish(t nstorage.EventType, k string, v []byte) {
+	expr.hub.publish(nstorage.Event{Type: t, Key: k, Value: v})
+}
+
+// scheduleExpiry schedules an expire event for giving key
+// if it has an expiration and there are watchers.
+func (expr *ExprByteStore) scheduleExpiry(k string) {
+	if !expr.hub.active() {
+		return
+	}
+
+	var ttl = expr.cache.TTL(k)
+	if ttl <= 0 {
+		expr.hub.cancelExpiry(k)
+		return
+	}
+
+	expr.hub.scheduleExpiry(k, ttl, func() bool {
+		var expired bool
+		expr.cache.GetMany(func(values map[string]ExpiringValue) {
+			if value, ok := values[k]; ok {
+				expired = value.Expired()
+			}
+		})
+		return expired
+	})
+}
+
+type exprWatch struct {
+	prefix string
+	ctx    context.Context
+	events chan nstorage.Event
+}
+
+// exprHub broadcasts events of a ExprByteStore to all it's watchers.
+type exprHub struct {
+	lock    sync.RWMutex
+	watches map[*exprWatch]struct{}
+
+	timerLock sync.Mutex
+	timers    map[string]*time.Timer
+}
+
+func (h *exprHub) watch(ctx context.Context, prefix string) <-chan nstorage.Event {
+	var w = &exprWatch{
+		ctx:    ctx,
+		prefix: prefix,
+		events: make(chan nstorage.Event, watchBuffer),
+	}
+
+	h.lock.Lock()
+	if h.watches == nil {
+		h.watches = map[*exprWatch]struct{}{}
+	}
+	h.watches[w] = struct{}{}
+	h.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		h.lock.Lock()
+		delete(h.watches, w)
+		h.lock.Unlock()
+
+		close(w.events)
+	}()
+
+	return w.events
+}
+
+func (h *exprHub) active() bool {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return len(h.watches) != 0
+}
+
+func (h *exprHub) publish(ev nstorage.Event) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	for w := range h.watches {
+		if !strings.HasPrefix(ev.Key, w.prefix) {
+			continue
+		}
+
+		var wev = ev
+		if ev.Value != nil {
+			wev.Value = copyBytes(ev.Value)
+		}
+
+		select {
+		case w.events <- wev:
+		case <-w.ctx.Done():
+		}
+	}
+}
+
+// scheduleExpiry replaces any existing expiry timer for giving key
+// with one firing after ttl, which publishes an expire event if
+// expired returns true.
+func (h *exprHub) scheduleExpiry(k string, ttl time.Duration, expired func() bool) {
+	h.timerLock.Lock()
+	defer h.timerLock.Unlock()
+
+	if h.timers == nil {
+		h.timers = map[string]*time.Timer{}
+	}
+	if timer, ok := h.timers[k]; ok {
+		timer.Stop()
+	}
+
+	// fire slightly after expiration, as values are only
+	// expired once the current time is past their expiry time.
+	var timer *time.Timer
+	timer = time.AfterFunc(ttl+time.Millisecond, func() {
+		h.timerLock.Lock()
+		if h.timers[k] != timer {
+			h.timerLock.Unlock()
+			return
+		}
+		delete(h.timers, k)
+		h.timerLock.Unlock()
+
+		if expired() {
+			h.publish(nstorage.Event{Type: nstorage.ExpireEvent, Key: k})
+		}
+	})
+	h.timers[k] = timer
+}
+
+func (h *exprHub) cancelExpiry(k string) {
+	h.timerLock.Lock()
+	defer h.timerLock.Unlock()
+
+	if timer, ok := h.timers[k]; ok {
+		timer.Stop()
+		delete(h.timers, k)
+	}
+}
@@ -216,3 +216,23 @@ func TestIntegrationRedisStoreExpirables(t *testing.T) {
 
 	tharness.TestExpirableStore(t, store)
 }
+
+func TestIntegrationRedisStoreWatchableExpiry(t *testing.T) {
+	var ops redis.Options
+	require.NotNil(t, &ops)
+
+	var redisClient = redis.NewClient(&ops)
+	require.NotNil(t, redisClient)
+
+	if err := redisClient.Ping().Err(); err != nil {
+		t.SkipNow()
+		return
+	}
+
+	var store, err = FromRedisStore("testing_mb", redisClient)
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	require.NoError(t, store.EnableKeyspaceEvents())
+
+	tharness.TestWatchableExpiry(t, store)
+}
@@ -13,6 +13,7 @@ import (
 )
 
 var _ nstorage.ExpirableStore = (*RedisStore)(nil)
+var _ nstorage.TxStore = (*RedisStore)(nil)
 
 // RedisStore implements session management, storage and access using redis as
 // underline store.
@@ -596,3 +597,69 @@ func (rd *RedisStore) Remove(key string) ([]byte, error) {
 	}
 	return nunsafe.String2Bytes(nstatus.Val()), nil
 }
+
+// Begin returns a new transaction which queues all operations
+// into a MULTI/EXEC pipeline, executed on commit.
+func (rd *RedisStore) Begin() (nstorage.Tx, error) {
+	return &redisTx{store: rd, pipe: rd.Client.TxPipeline()}, nil
+}
+
+type redisTx struct {
+	store *RedisStore
+	pipe  redis.Pipeliner
+	done  bool
+}
+
+// Put queues the saving of giving key and value.
+func (tx *redisTx) Put(key string, data []byte) error {
+	if tx.done {
+		return nstorage.ErrTxDone
+	}
+
+	var hashKey = tx.store.doHashKey(key)
+
+	var zs redis.Z
+	zs.Score = 0
+	zs.Member = hashKey
+
+	tx.pipe.SAdd(tx.store.hashList, hashKey)
+	tx.pipe.ZAdd(tx.store.hashZList, zs)
+	tx.pipe.Set(hashKey, data, 0)
+	return nil
+}
+
+// Delete queues the removal of giving key.
+func (tx *redisTx) Delete(key string) error {
+	if tx.done {
+		return nstorage.ErrTxDone
+	}
+
+	var hashKey = tx.store.doHashKey(key)
+	tx.pipe.ZRem(tx.store.hashZList, hashKey)
+	tx.pipe.SRem(tx.store.hashList, hashKey)
+	tx.pipe.Del(hashKey)
+	return nil
+}
+
+// Commit executes all queued operations within a MULTI/EXEC block.
+func (tx *redisTx) Commit() error {
+	if tx.done {
+		return nstorage.ErrTxDone
+	}
+	tx.done = true
+
+	defer tx.pipe.Close()
+	if _, err := tx.pipe.Exec(); err != nil && err != redis.Nil {
+		return nerror.WrapOnly(err)
+	}
+	return nil
+}
+
+// Rollback discards all queued operations.
+func (tx *redisTx) Rollback() error {
+	if tx.done {
+		return nil
+	}
+	tx.done = true
+	return tx.pipe.Close()
+}
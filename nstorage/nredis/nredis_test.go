@@ -35,3 +35,27 @@ func TestRedisStore(t *testing.T) {
 
 	tharness.TestByteStore(t, store)
 }
+
+func TestRedisTxStore(t *testing.T) {
+	var server = miniredis.NewMiniRedis()
+	require.NotNil(t, server)
+
+	var err = server.StartAddr("localhost:0")
+	require.NoError(t, err)
+
+	defer server.Close()
+
+	var ops redis.Options
+	ops.Addr = server.Addr()
+	ops.Network = "tcp"
+
+	var redisClient = redis.NewClient(&ops)
+	require.NotNil(t, redisClient)
+
+	var store *RedisStore
+	store, err = FromRedisStore("testing_mb", redisClient)
+	require.NoError(t, err)
+	require.NotNil(t, store)
+
+	tharness.TestTxStore(t, store)
+}
@@ -0,0 +1,130 @@
+package nredis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/influx6/npkg/nerror"
+	"github.com/influx6/npkg/nstorage"
+	"github.com/influx6/npkg/nunsafe"
+)
+
+const watchBuffer = 100
+
+// keyspaceEventFlags are the notify-keyspace-events flags needed by Watch:
+// keyspace events (K) for generic (g), string ($) and expired (x) events.
+const keyspaceEventFlags = "Kg$x"
+
+var _ nstorage.WatchableStore = (*RedisStore)(nil)
+
+// EnableKeyspaceEvents adds the keyspace notification flags required by Watch
+// to the redis server's notify-keyspace-events configuration, keeping
+// already set flags.
+//
+// Servers which disallow CONFIG SET must be configured with at least
+// the "Kg$x" flags for Watch to work.
+func (rd *RedisStore) EnableKeyspaceEvents() error {
+	var current = rd.Client.ConfigGet("notify-keyspace-events")
+	if err := current.Err(); err != nil {
+		return nerror.WrapOnly(err)
+	}
+
+	var flags string
+	if values := current.Val(); len(values) == 2 {
+		flags, _ = values[1].(string)
+	}
+
+	for _, flag := range keyspaceEventFlags {
+		if !strings.ContainsRune(flags, flag) {
+			flags += string(flag)
+		}
+	}
+
+	if err := rd.Client.ConfigSet("notify-keyspace-events", flags).Err(); err != nil {
+		return nerror.WrapOnly(err)
+	}
+	return nil
+}
+
+// Watch returns a channel receiving events for all keys starting with
+// giving prefix, using redis keyspace notifications.
+//
+// Redis reports all writes of a key alike, hence saves and updates are both
+// delivered as nstorage.PutEvent, with the value read when the notification
+// is received. See EnableKeyspaceEvents for the required server configuration.
+func (rd *RedisStore) Watch(ctx context.Context, prefix string) (<-chan nstorage.Event, error) {
+	var channelPrefix = "__keyspace@" + strconv.Itoa(rd.Client.Options().DB) + "__:"
+	var pattern = channelPrefix + escapeGlob(rd.doHashKey(prefix)) + "*"
+
+	var pubsub = rd.Client.PSubscribe(pattern)
+
+	// wait for subscription confirmation, so no event is missed
+	// after we return.
+	if _, err := pubsub.Receive(); err != nil {
+		_ = pubsub.Close()
+		return nil, nerror.WrapOnly(err)
+	}
+
+	var events = make(chan nstorage.Event, watchBuffer)
+	var messages = pubsub.ChannelSize(watchBuffer)
+
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				var hashKey = strings.TrimPrefix(msg.Channel, channelPrefix)
+				var event = nstorage.Event{Key: rd.unHashKey(hashKey)}
+
+				switch msg.Payload {
+				case "set":
+					var value, err = rd.Client.Get(hashKey).Result()
+					if err != nil {
+						// key was removed before we could read it, the
+						// following event will report this.
+						continue
+					}
+					event.Type = nstorage.PutEvent
+					event.Value = nunsafe.String2Bytes(value)
+				case "del":
+					event.Type = nstorage.DeleteEvent
+				case "expired":
+					event.Type = nstorage.ExpireEvent
+				default:
+					continue
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// escapeGlob escapes all glob special characters in giving value
+// for use in a redis pattern.
+func escapeGlob(value string) string {
+	var escaped strings.Builder
+	for _, c := range value {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(c)
+	}
+	return escaped.String()
+}
@@ -0,0 +1,580 @@
+package nsql
+
+import (
+	"context"
+	"database/sql"
+	regexp2 "regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influx6/npkg/nerror"
+	"github.com/influx6/npkg/nstorage"
+)
+
+var _ nstorage.ExpirableStore = (*SQLStore)(nil)
+var _ nstorage.TxStore = (*SQLStore)(nil)
+
+const (
+	defaultPageSize       = 100
+	defaultExpiryInterval = time.Minute
+)
+
+var tableNameRegEx = regexp2.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Options defines configuration for a SQLStore.
+type Options struct {
+	// Table is the name of the table used by the store, it is
+	// created if it does not exists.
+	Table string
+
+	// ExpiryInterval sets the interval at which expired keys are deleted
+	// from the table. Defaults to a minute, a negative value disables it.
+	ExpiryInterval time.Duration
+
+	// PageSize sets the number of rows read per query when iterating
+	// keys. Defaults to 100.
+	PageSize int
+}
+
+// SQLStore implements the nstorage.ExpirableStore on a database/sql
+// table, with a row per key.
+//
+// Expired keys are hidden from all reads once their expiration is
+// reached and deleted from the table periodically.
+//
+// Queries are written for SQLite and Postgres. Parameters use the $N
+// form, which SQLite binds by order of first appearance, hence all
+// queries must use them in order.
+type SQLStore struct {
+	db      *sql.DB
+	ops     Options
+	table   string
+	cancel  context.CancelFunc
+	waiter  sync.WaitGroup
+	queries queries
+}
+
+type queries struct {
+	count      string
+	keys       string
+	page       string
+	exists     string
+	get        string
+	ttl        string
+	upsert     string
+	update     string
+	extendTTL  string
+	resetTTL   string
+	remove     string
+	removeExpr string
+}
+
+// NewSQLStore returns a new instance of a SQLStore, creating it's table
+// if it does not exists.
+//
+// Closing the store does not close giving db.
+func NewSQLStore(db *sql.DB, ops Options) (*SQLStore, error) {
+	if !tableNameRegEx.MatchString(ops.Table) {
+		return nil, nerror.New("Options.Table must be a valid table name").Add("table", ops.Table)
+	}
+	if ops.ExpiryInterval == 0 {
+		ops.ExpiryInterval = defaultExpiryInterval
+	}
+	if ops.PageSize <= 0 {
+		ops.PageSize = defaultPageSize
+	}
+
+	var store SQLStore
+	store.db = db
+	store.ops = ops
+	store.table = ops.Table
+	store.queries = newQueries(ops.Table)
+
+	if err := store.createTable(); err != nil {
+		return nil, err
+	}
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	store.cancel = cancel
+	if ops.ExpiryInterval > 0 {
+		store.waiter.Add(1)
+		go store.expireKeys(ctx)
+	}
+
+	return &store, nil
+}
+
+func newQueries(table string) queries {
+	return queries{
+		count:  "SELECT COUNT(*) FROM " + table + " WHERE " + liveAt(1),
+		keys:   "SELECT id FROM " + table + " WHERE " + liveAt(1) + " ORDER BY id",
+		page:   "SELECT id, value FROM " + table + " WHERE id > $1 AND " + liveAt(2) + " ORDER BY id LIMIT $3",
+		exists: "SELECT 1 FROM " + table + " WHERE id = $1 AND " + liveAt(2),
+		get:    "SELECT value FROM " + table + " WHERE id = $1 AND " + liveAt(2),
+		ttl:    "SELECT expires_at FROM " + table + " WHERE id = $1 AND " + liveAt(2),
+		upsert: "INSERT INTO " + table + " (id, value, expires_at) VALUES ($1, $2, $3) " +
+			"ON CONFLICT (id) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at",
+		update: "UPDATE " + table + " SET value = $1, expires_at = $2 WHERE id = $3 AND " + liveAt(4),
+		extendTTL: "UPDATE " + table + " SET expires_at = CASE WHEN expires_at = 0 THEN $1 ELSE expires_at + $2 END " +
+			"WHERE id = $3 AND " + liveAt(4),
+		resetTTL:   "UPDATE " + table + " SET expires_at = $1 WHERE id = $2 AND " + liveAt(3),
+		remove:     "DELETE FROM " + table + " WHERE id = $1",
+		removeExpr: "DELETE FROM " + table + " WHERE expires_at != 0 AND expires_at <= $1",
+	}
+}
+
+// Close stops the periodic deletion of expired keys.
+func (s *SQLStore) Close() error {
+	s.cancel()
+	s.waiter.Wait()
+	return nil
+}
+
+// RemoveExpired deletes all expired keys from the table.
+func (s *SQLStore) RemoveExpired() error {
+	if _, err := s.db.Exec(s.queries.removeExpr, now()); err != nil {
+		return nerror.WrapOnly(err)
+	}
+	return nil
+}
+
+// Count returns the total count of live keys in the store.
+func (s *SQLStore) Count() (int64, error) {
+	var count int64
+	if err := s.db.QueryRow(s.queries.count, now()).Scan(&count); err != nil {
+		return -1, nerror.WrapOnly(err)
+	}
+	return count, nil
+}
+
+// Keys returns all keys of the store in sorted order.
+func (s *SQLStore) Keys() ([]string, error) {
+	var rows, err = s.db.Query(s.queries.keys, now())
+	if err != nil {
+		return nil, nerror.WrapOnly(err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return keys, nerror.WrapOnly(err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return keys, nerror.WrapOnly(err)
+	}
+	return keys, nil
+}
+
+// Each runs through all keys and values in the store in sorted key order,
+// reading them a page at a time.
+//
+// Return nstorage.ErrJustStop if you want to just stop iterating.
+func (s *SQLStore) Each(fn nstorage.EachItem) error {
+	var lastKey string
+	for {
+		var keys, values, err = s.page(lastKey, s.ops.PageSize)
+		if err != nil {
+			return err
+		}
+
+		for index, key := range keys {
+			if err := fn(values[index], key); err != nil {
+				if nerror.IsAny(err, nstorage.ErrJustStop) {
+					return nil
+				}
+				return nerror.WrapOnly(err)
+			}
+		}
+
+		if len(keys) < s.ops.PageSize {
+			return nil
+		}
+		lastKey = keys[len(keys)-1]
+	}
+}
+
+// EachKeyMatch returns all keys matching giving regexp in sorted order.
+//
+// SQL offers no portable regexp matching, hence all keys are
+// read and matched in memory.
+func (s *SQLStore) EachKeyMatch(regexp string) ([]string, error) {
+	var result, err = s.ScanMatch(-1, 0, "", regexp)
+	return result.Keys, err
+}
+
+// ScanMatch returns count keys matching giving regexp after the lastKey in
+// sorted order, reading keys a page at a time till count keys are matched.
+// A negative count returns all matching keys.
+//
+// The LastKey of the returned result is the cursor for the next call,
+// with lastIndex only counting keys returned so far.
+func (s *SQLStore) ScanMatch(count int64, lastIndex int64, lastKey string, regexp string) (nstorage.ScanResult, error) {
+	var rs nstorage.ScanResult
+	rs.LastIndex = lastIndex
+	rs.LastKey = lastKey
+
+	if len(regexp) == 0 {
+		regexp = ".+"
+	}
+
+	var regx, rgErr = regexp2.Compile(regexp)
+	if rgErr != nil {
+		return rs, nerror.WrapOnly(rgErr)
+	}
+
+	for count < 0 || int64(len(rs.Keys)) < count {
+		var keys, _, err = s.page(rs.LastKey, s.ops.PageSize)
+		if err != nil {
+			return rs, err
+		}
+
+		var stopped bool
+		for index, key := range keys {
+			rs.LastKey = key
+			if !regx.MatchString(key) {
+				continue
+			}
+
+			rs.Keys = append(rs.Keys, key)
+			rs.LastIndex++
+			if count >= 0 && int64(len(rs.Keys)) == count {
+				stopped = index < len(keys)-1
+				break
+			}
+		}
+
+		if len(keys) < s.ops.PageSize {
+			rs.Finished = !stopped
+			break
+		}
+	}
+	return rs, nil
+}
+
+// Exists returns true/false if giving key exists.
+func (s *SQLStore) Exists(key string) (bool, error) {
+	var found int
+	var err = s.db.QueryRow(s.queries.exists, key, now()).Scan(&found)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, nerror.WrapOnly(err)
+	}
+	return true, nil
+}
+
+// Get returns the value of giving key.
+func (s *SQLStore) Get(key string) ([]byte, error) {
+	var value []byte
+	var err = s.db.QueryRow(s.queries.get, key, now()).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, nerror.New("not found")
+	}
+	if err != nil {
+		return nil, nerror.WrapOnly(err)
+	}
+	return value, nil
+}
+
+// GetAnyKeys returns a list of values for any of the key's found, with nil
+// set in place of keys not found.
+func (s *SQLStore) GetAnyKeys(keys ...string) ([][]byte, error) {
+	var found, err = s.getKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var values = make([][]byte, len(keys))
+	for index, key := range keys {
+		values[index] = found[key]
+	}
+	return values, nil
+}
+
+// GetAllKeys returns a list of values for all giving keys, returning an
+// error if any is not found.
+func (s *SQLStore) GetAllKeys(keys ...string) ([][]byte, error) {
+	var found, err = s.getKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var values = make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		var value, ok = found[key]
+		if !ok {
+			return values, nerror.New("not found").Add("key", key)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// TTL returns the remaining time before giving key expires.
+//
+// A zero value means it has no expiration.
+func (s *SQLStore) TTL(key string) (time.Duration, error) {
+	var current = now()
+
+	var expiresAt int64
+	var err = s.db.QueryRow(s.queries.ttl, key, current).Scan(&expiresAt)
+	if err == sql.ErrNoRows {
+		return 0, nerror.New("not found, possibly expired")
+	}
+	if err != nil {
+		return 0, nerror.WrapOnly(err)
+	}
+
+	if expiresAt == 0 {
+		return 0, nil
+	}
+	return time.Duration(expiresAt - current), nil
+}
+
+// Save adds giving key and value into store without an expiration.
+func (s *SQLStore) Save(key string, data []byte) error {
+	return s.SaveTTL(key, data, 0)
+}
+
+// SaveTTL adds giving key and value into store with giving expiration.
+//
+// A zero value means no expiration.
+func (s *SQLStore) SaveTTL(key string, data []byte, expiration time.Duration) error {
+	if _, err := s.db.Exec(s.queries.upsert, key, nonNil(data), expiresAt(expiration)); err != nil {
+		return nerror.WrapOnly(err)
+	}
+	return nil
+}
+
+// Update updates the value of an existing key, removing it's expiration.
+func (s *SQLStore) Update(key string, data []byte) error {
+	return s.UpdateTTL(key, data, 0)
+}
+
+// UpdateTTL updates the value of an existing key with a new expiration.
+//
+// A zero value persists the key.
+func (s *SQLStore) UpdateTTL(key string, data []byte, expiration time.Duration) error {
+	return s.execFound(s.queries.update, nonNil(data), expiresAt(expiration), key, now())
+}
+
+// ExtendTTL extends the expiration of giving key by provided duration.
+//
+// A zero value persists the key.
+func (s *SQLStore) ExtendTTL(key string, expiration time.Duration) error {
+	if expiration <= 0 {
+		return s.ResetTTL(key, 0)
+	}
+	return s.execFound(s.queries.extendTTL, expiresAt(expiration), int64(expiration), key, now())
+}
+
+// ResetTTL resets the expiration of giving key to provided duration.
+//
+// A zero value persists the key.
+func (s *SQLStore) ResetTTL(key string, expiration time.Duration) error {
+	return s.execFound(s.queries.resetTTL, expiresAt(expiration), key, now())
+}
+
+// Remove removes giving key from the store, returning it's value.
+func (s *SQLStore) Remove(key string) ([]byte, error) {
+	var tx, err = s.db.Begin()
+	if err != nil {
+		return nil, nerror.WrapOnly(err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var value []byte
+	err = tx.QueryRow(s.queries.get, key, now()).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, nerror.New("not found")
+	}
+	if err != nil {
+		return nil, nerror.WrapOnly(err)
+	}
+
+	if _, err := tx.Exec(s.queries.remove, key); err != nil {
+		return nil, nerror.WrapOnly(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nerror.WrapOnly(err)
+	}
+	return value, nil
+}
+
+// RemoveKeys removes all giving keys from the store.
+func (s *SQLStore) RemoveKeys(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	var query = "DELETE FROM " + s.table + " WHERE id IN (" + placeholders(1, len(keys)) + ")"
+	if _, err := s.db.Exec(query, stringArgs(keys)...); err != nil {
+		return nerror.WrapOnly(err)
+	}
+	return nil
+}
+
+// *****************************************************
+// internal methods
+// *****************************************************
+
+func (s *SQLStore) createTable() error {
+	var statements = []string{
+		"CREATE TABLE IF NOT EXISTS " + s.table + " (" +
+			"id TEXT PRIMARY KEY, " +
+			"value BYTEA NOT NULL, " +
+			"expires_at BIGINT NOT NULL DEFAULT 0)",
+		"CREATE INDEX IF NOT EXISTS " + s.table + "_expires_at ON " + s.table + " (expires_at)",
+	}
+
+	for _, statement := range statements {
+		if _, err := s.db.Exec(statement); err != nil {
+			return nerror.WrapOnly(err)
+		}
+	}
+	return nil
+}
+
+// expireKeys deletes expired keys every interval till giving
+// context is cancelled.
+func (s *SQLStore) expireKeys(ctx context.Context) {
+	defer s.waiter.Done()
+
+	var ticker = time.NewTicker(s.ops.ExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// failures are retried on the next tick, as expired keys
+			// are never visible to reads.
+			_ = s.RemoveExpired()
+		}
+	}
+}
+
+// execFound executes giving query, returning an error if no row was affected.
+func (s *SQLStore) execFound(query string, args ...interface{}) error {
+	var result, err = s.db.Exec(query, args...)
+	if err != nil {
+		return nerror.WrapOnly(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nerror.WrapOnly(err)
+	}
+	if affected == 0 {
+		return nerror.New("not found, possibly expired")
+	}
+	return nil
+}
+
+// page returns up to size keys and values after giving key in sorted order.
+func (s *SQLStore) page(afterKey string, size int) ([]string, [][]byte, error) {
+	var rows, err = s.db.Query(s.queries.page, afterKey, now(), size)
+	if err != nil {
+		return nil, nil, nerror.WrapOnly(err)
+	}
+	defer rows.Close()
+
+	var keys = make([]string, 0, size)
+	var values = make([][]byte, 0, size)
+	for rows.Next() {
+		var key string
+		var value []byte
+		if err := rows.Scan(&key, &value); err != nil {
+			return keys, values, nerror.WrapOnly(err)
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	if err := rows.Err(); err != nil {
+		return keys, values, nerror.WrapOnly(err)
+	}
+	return keys, values, nil
+}
+
+// getKeys returns a map of the live keys found from giving list.
+func (s *SQLStore) getKeys(keys []string) (map[string][]byte, error) {
+	var found = make(map[string][]byte, len(keys))
+	if len(keys) == 0 {
+		return found, nil
+	}
+
+	var query = "SELECT id, value FROM " + s.table + " WHERE id IN (" + placeholders(1, len(keys)) + ") " +
+		"AND " + liveAt(len(keys)+1)
+
+	var rows, err = s.db.Query(query, append(stringArgs(keys), now())...)
+	if err != nil {
+		return nil, nerror.WrapOnly(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var value []byte
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, nerror.WrapOnly(err)
+		}
+		found[key] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nerror.WrapOnly(err)
+	}
+	return found, nil
+}
+
+// liveAt returns the condition matching unexpired rows, comparing
+// against the current time given as the parameter at index.
+func liveAt(index int) string {
+	return "(expires_at = 0 OR expires_at > $" + strconv.Itoa(index) + ")"
+}
+
+// placeholders returns a comma separated list of count
+// parameters starting from $start.
+func placeholders(start int, count int) string {
+	var params = make([]string, count)
+	for index := range params {
+		params[index] = "$" + strconv.Itoa(start+index)
+	}
+	return strings.Join(params, ", ")
+}
+
+func stringArgs(values []string) []interface{} {
+	var args = make([]interface{}, len(values))
+	for index, value := range values {
+		args[index] = value
+	}
+	return args
+}
+
+func expiresAt(expiration time.Duration) int64 {
+	if expiration <= 0 {
+		return 0
+	}
+	return time.Now().Add(expiration).UnixNano()
+}
+
+func now() int64 {
+	return time.Now().UnixNano()
+}
+
+// nonNil returns an empty slice for a nil value, as nil is stored as NULL.
+func nonNil(value []byte) []byte {
+	if value == nil {
+		return []byte{}
+	}
+	return value
+}
@@ -0,0 +1,179 @@
+package nsql
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influx6/npkg/nstorage"
+	"github.com/influx6/npkg/nstorage/internal/tharness"
+)
+
+func openDB(t *testing.T) *sql.DB {
+	var db, err = sql.Open("sqlite3", filepath.Join(t.TempDir(), "store.db"))
+	require.NoError(t, err)
+
+	// sqlite allows a single writer, sharing one connection
+	// avoids busy errors between concurrent writes.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+func TestStoreWithSQLStoreRemoveKeys(t *testing.T) {
+	var store, err = NewSQLStore(openDB(t), Options{Table: "store"})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestByteStoreRemoveKeys(t, store)
+}
+
+func TestStoreWithSQLStoreScanMatch(t *testing.T) {
+	var store, err = NewSQLStore(openDB(t), Options{Table: "store"})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestByteStoreScanMatch(t, store)
+}
+
+func TestStoreWithSQLStoreGetAnykeys(t *testing.T) {
+	var store, err = NewSQLStore(openDB(t), Options{Table: "store"})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestByteStoreGetAnykeys(t, store)
+}
+
+func TestStoreWithSQLStoreGetAllkeys(t *testing.T) {
+	var store, err = NewSQLStore(openDB(t), Options{Table: "store"})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestByteStoreGetAllkeys(t, store)
+}
+
+func TestStoreWithSQLStoreFindEach(t *testing.T) {
+	var store, err = NewSQLStore(openDB(t), Options{Table: "store"})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestByteStoreFindEach(t, store)
+}
+
+func TestStoreWithSQLStoreFindPrefix(t *testing.T) {
+	var store, err = NewSQLStore(openDB(t), Options{Table: "store"})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestByteStoreFindPrefix(t, store)
+}
+
+func TestStoreWithSQLStoreFindAll(t *testing.T) {
+	var store, err = NewSQLStore(openDB(t), Options{Table: "store"})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestByteStoreFindAll(t, store)
+}
+
+func TestStoreWithSQLStore(t *testing.T) {
+	var store, err = NewSQLStore(openDB(t), Options{Table: "store"})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestByteStore(t, store)
+}
+
+func TestSQLExpiryStore(t *testing.T) {
+	var store, err = NewSQLStore(openDB(t), Options{Table: "store"})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestExpirableStore(t, store)
+}
+
+func TestSQLExpiryStoreReset(t *testing.T) {
+	var store, err = NewSQLStore(openDB(t), Options{Table: "store"})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestExpiryReset(t, store)
+}
+
+func TestSQLTxStore(t *testing.T) {
+	var store, err = NewSQLStore(openDB(t), Options{Table: "store"})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	defer store.Close()
+
+	tharness.TestTxStore(t, store)
+}
+
+func TestSQLStoreScanMatchPages(t *testing.T) {
+	var store, err = NewSQLStore(openDB(t), Options{Table: "store", PageSize: 3})
+	require.NoError(t, err)
+	defer store.Close()
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, store.Save(fmt.Sprintf("day-%d", i), []byte("i")))
+		require.NoError(t, store.Save(fmt.Sprintf("night-%d", i), []byte("i")))
+	}
+
+	var keys []string
+	var result nstorage.ScanResult
+	for !result.Finished {
+		result, err = store.ScanMatch(4, result.LastIndex, result.LastKey, "night-.+")
+		require.NoError(t, err)
+		keys = append(keys, result.Keys...)
+	}
+
+	require.Len(t, keys, 10)
+	require.Equal(t, "night-0", keys[0])
+	require.Equal(t, "night-9", keys[9])
+	require.Equal(t, int64(10), result.LastIndex)
+}
+
+func TestSQLStoreRemoveExpired(t *testing.T) {
+	var db = openDB(t)
+	var store, err = NewSQLStore(db, Options{Table: "store", ExpiryInterval: 50 * time.Millisecond})
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.NoError(t, store.Save("day", []byte("wrecker")))
+	require.NoError(t, store.SaveTTL("night", []byte("sleeper"), 10*time.Millisecond))
+
+	time.Sleep(20 * time.Millisecond)
+
+	// expired keys are hidden before they are deleted.
+	var exist, existErr = store.Exists("night")
+	require.NoError(t, existErr)
+	require.False(t, exist)
+
+	require.Eventually(t, func() bool {
+		var rows int
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM store").Scan(&rows))
+		return rows == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestSQLStoreInvalidTable(t *testing.T) {
+	var _, err = NewSQLStore(openDB(t), Options{Table: "store; DROP TABLE users"})
+	require.Error(t, err)
+}
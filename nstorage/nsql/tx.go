@@ -0,0 +1,83 @@
+package nsql
+
+import (
+	"github.com/influx6/npkg/nerror"
+	"github.com/influx6/npkg/nstorage"
+)
+
+// Begin returns a new transaction which applies all it's operations
+// within a single database transaction on commit.
+func (s *SQLStore) Begin() (nstorage.Tx, error) {
+	return &sqlTx{store: s}, nil
+}
+
+type sqlTxOp struct {
+	key    string
+	value  []byte
+	delete bool
+}
+
+type sqlTx struct {
+	store *SQLStore
+	ops   []sqlTxOp
+	done  bool
+}
+
+// Put buffers the saving of giving key and value.
+func (tx *sqlTx) Put(k string, v []byte) error {
+	if tx.done {
+		return nstorage.ErrTxDone
+	}
+	var cm = append(make([]byte, 0, len(v)), v...)
+	tx.ops = append(tx.ops, sqlTxOp{key: k, value: cm})
+	return nil
+}
+
+// Delete buffers the removal of giving key.
+func (tx *sqlTx) Delete(k string) error {
+	if tx.done {
+		return nstorage.ErrTxDone
+	}
+	tx.ops = append(tx.ops, sqlTxOp{key: k, delete: true})
+	return nil
+}
+
+// Commit applies all buffered operations in a database transaction.
+func (tx *sqlTx) Commit() error {
+	if tx.done {
+		return nstorage.ErrTxDone
+	}
+	tx.done = true
+
+	var ops = tx.ops
+	tx.ops = nil
+
+	var dbTx, err = tx.store.db.Begin()
+	if err != nil {
+		return nerror.WrapOnly(err)
+	}
+
+	for _, op := range ops {
+		if op.delete {
+			_, err = dbTx.Exec(tx.store.queries.remove, op.key)
+		} else {
+			_, err = dbTx.Exec(tx.store.queries.upsert, op.key, op.value, 0)
+		}
+		if err != nil {
+			_ = dbTx.Rollback()
+			return nerror.WrapOnly(err)
+		}
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return nerror.WrapOnly(err)
+	}
+	return nil
+}
+
+// Rollback discards all buffered operations.
+func (tx *sqlTx) Rollback() error {
+	tx.done = true
+	tx.ops = nil
+	return nil
+}
@@ -1,6 +1,7 @@
 package nstorage
 
 import (
+	"context"
 	"time"
 
 	"github.com/influx6/npkg/nerror"
@@ -69,3 +70,87 @@ type ExpirableStore interface {
 	// A zero value should persist key.
 	UpdateTTL(string, []byte, time.Duration) error
 }
+
+// ErrTxDone is returned when a committed or rolled back transaction
+// is used.
+var ErrTxDone = nerror.New("transaction already committed or rolled back")
+
+// Tx defines a batch of write operations which are applied atomically
+// on Commit or discarded on Rollback.
+//
+// Operations are not visible to the store until Commit is called. A call to
+// Rollback after Commit is a no-op, allowing Rollback to be deferred safely.
+type Tx interface {
+	// Put adds giving key and value into transaction, creating or
+	// replacing key without an expiration on commit.
+	Put(string, []byte) error
+
+	// Delete adds removal of giving key into transaction.
+	Delete(string) error
+
+	// Commit applies all operations of the transaction.
+	Commit() error
+
+	// Rollback discards all operations of the transaction.
+	Rollback() error
+}
+
+// TxStore defines an optional interface which a ByteStore implements
+// to support atomic writes of multiple keys.
+type TxStore interface {
+	// Begin returns a new transaction for the store.
+	Begin() (Tx, error)
+}
+
+// EventType defines the kind of change an Event represents.
+type EventType int
+
+// set of event types.
+const (
+	// PutEvent is emitted when a new key is saved.
+	PutEvent EventType = iota + 1
+
+	// UpdateEvent is emitted when the value of an existing key is replaced.
+	UpdateEvent
+
+	// DeleteEvent is emitted when a key is removed.
+	DeleteEvent
+
+	// ExpireEvent is emitted when a key is dropped due to it's expiration.
+	ExpireEvent
+)
+
+// String returns the name of the event type.
+func (e EventType) String() string {
+	switch e {
+	case PutEvent:
+		return "put"
+	case UpdateEvent:
+		return "update"
+	case DeleteEvent:
+		return "delete"
+	case ExpireEvent:
+		return "expire"
+	}
+	return "unknown"
+}
+
+// Event defines a change to a key within a store.
+//
+// Value is set for PutEvent and UpdateEvent, it is owned by
+// the receiver.
+type Event struct {
+	Type  EventType
+	Key   string
+	Value []byte
+}
+
+// WatchableStore defines an optional interface which a ByteStore implements
+// to support subscribing to changes of keys.
+type WatchableStore interface {
+	// Watch returns a channel which receives events for all keys starting
+	// with giving prefix, an empty prefix matches all keys.
+	//
+	// The channel is closed once the context is cancelled.
+	Watch(ctx context.Context, prefix string) (<-chan Event, error)
+}
@@ -0,0 +1,439 @@
+package ntiered
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/influx6/npkg/nerror"
+	"github.com/influx6/npkg/nstorage"
+)
+
+var _ nstorage.ByteStore = (*TieredStore)(nil)
+
+// ErrClosed is returned when writing to a closed TieredStore.
+var ErrClosed = nerror.New("tiered store is closed")
+
+// WriteMode defines how writes reach the L2 store.
+type WriteMode int
+
+const (
+	// WriteThrough writes to the L2 store before the L1 cache,
+	// returning once both are written.
+	WriteThrough WriteMode = iota
+
+	// WriteBehind writes to the L1 cache, queuing the write of the L2
+	// store in the background in order of calls.
+	WriteBehind
+)
+
+const defaultWriteBehindBuffer = 1000
+
+// marks prefixed to all values in the L1 cache, to tell cached
+// values from keys cached as missing.
+const (
+	missingMark byte = iota
+	valueMark
+)
+
+// Options defines configuration for a TieredStore.
+type Options struct {
+	// Mode sets how writes reach the L2 store, defaults to WriteThrough.
+	Mode WriteMode
+
+	// TTL sets the expiration of values cached in L1, a zero
+	// value caches them without expiration.
+	TTL time.Duration
+
+	// NegativeTTL sets the expiration of keys cached in L1 as missing from
+	// L2, sparing L2 repeated reads of missing keys. A zero value disables
+	// negative caching.
+	NegativeTTL time.Duration
+
+	// WriteBehindBuffer sets the number of writes queued for L2 in
+	// WriteBehind mode, before writers block. Defaults to 1000.
+	WriteBehindBuffer int
+
+	// OnWriteError is called with the key and error of any write to L2
+	// which failed in WriteBehind mode.
+	OnWriteError func(key string, err error)
+
+	// Watch subscribes to changes of the L2 store, which must implement
+	// nstorage.WatchableStore, invalidating L1 for every key changed,
+	// removed or expired in L2, including by other writers.
+	Watch bool
+}
+
+// TieredStore implements the nstorage.ByteStore, composing an L1
+// ExpirableStore used as a cache in front of an L2 ByteStore.
+//
+// Reads go through L1, loading missing keys from L2 into L1. Writes go
+// to both stores based on the WriteMode, and removals from L2 invalidate
+// L1. Keys, Count and all iteration are served by L2, which may lag
+// behind queued writes in WriteBehind mode, till Flush is called.
+type TieredStore struct {
+	l1  nstorage.ExpirableStore
+	l2  nstorage.ByteStore
+	ops Options
+
+	cancel context.CancelFunc
+	waiter sync.WaitGroup
+
+	closeLock sync.RWMutex
+	closed    bool
+	writes    chan tieredWrite
+
+	pendingLock sync.Mutex
+	pending     map[string]pendingWrite
+	version     uint64
+}
+
+// NewTieredStore returns a new instance of a TieredStore.
+//
+// The L1 store is owned by the TieredStore and must not be
+// written to by others.
+func NewTieredStore(l1 nstorage.ExpirableStore, l2 nstorage.ByteStore, ops Options) (*TieredStore, error) {
+	if ops.WriteBehindBuffer <= 0 {
+		ops.WriteBehindBuffer = defaultWriteBehindBuffer
+	}
+
+	var ts TieredStore
+	ts.l1 = l1
+	ts.l2 = l2
+	ts.ops = ops
+	ts.pending = map[string]pendingWrite{}
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	ts.cancel = cancel
+
+	if ops.Watch {
+		var watchable, ok = l2.(nstorage.WatchableStore)
+		if !ok {
+			cancel()
+			return nil, nerror.New("L2 store does not implement nstorage.WatchableStore")
+		}
+
+		var events, err = watchable.Watch(ctx, "")
+		if err != nil {
+			cancel()
+			return nil, nerror.WrapOnly(err)
+		}
+
+		ts.waiter.Add(1)
+		go ts.invalidate(events)
+	}
+
+	if ops.Mode == WriteBehind {
+		ts.writes = make(chan tieredWrite, ops.WriteBehindBuffer)
+		ts.waiter.Add(1)
+		go ts.writeBehind()
+	}
+
+	return &ts, nil
+}
+
+// Close flushes all queued writes and stops the store.
+func (ts *TieredStore) Close() error {
+	ts.closeLock.Lock()
+	if ts.closed {
+		ts.closeLock.Unlock()
+		return nil
+	}
+	ts.closed = true
+	if ts.writes != nil {
+		close(ts.writes)
+	}
+	ts.closeLock.Unlock()
+
+	ts.cancel()
+	ts.waiter.Wait()
+	return nil
+}
+
+// Flush blocks till all writes queued before the call are written to L2.
+func (ts *TieredStore) Flush() error {
+	if ts.ops.Mode != WriteBehind {
+		return nil
+	}
+
+	var done = make(chan struct{})
+	if err := ts.enqueue(tieredWrite{flushed: done}); err != nil {
+		return err
+	}
+	<-done
+	return nil
+}
+
+// Count returns the total count of keys in L2.
+func (ts *TieredStore) Count() (int64, error) {
+	return ts.l2.Count()
+}
+
+// Keys returns all keys of L2.
+func (ts *TieredStore) Keys() ([]string, error) {
+	return ts.l2.Keys()
+}
+
+// Each runs through all keys and values of L2.
+func (ts *TieredStore) Each(fn nstorage.EachItem) error {
+	return ts.l2.Each(fn)
+}
+
+// EachKeyMatch returns all keys of L2 matching giving regexp.
+func (ts *TieredStore) EachKeyMatch(regexp string) ([]string, error) {
+	return ts.l2.EachKeyMatch(regexp)
+}
+
+// ScanMatch scans the keys of L2 matching giving regexp.
+func (ts *TieredStore) ScanMatch(count int64, lastIndex int64, lastKey string, regexp string) (nstorage.ScanResult, error) {
+	return ts.l2.ScanMatch(count, lastIndex, lastKey, regexp)
+}
+
+// Exists returns true/false if giving key exists.
+func (ts *TieredStore) Exists(key string) (bool, error) {
+	if _, removed, ok := ts.getPending(key); ok {
+		return !removed, nil
+	}
+	if _, missing, ok := ts.cached(key); ok {
+		return !missing, nil
+	}
+
+	var exists, err = ts.l2.Exists(key)
+	if err != nil {
+		return false, nerror.WrapOnly(err)
+	}
+	if !exists {
+		ts.cacheMissing(key)
+	}
+	return exists, nil
+}
+
+// Get returns the value of giving key, reading it from L2 into
+// L1 if not cached.
+func (ts *TieredStore) Get(key string) ([]byte, error) {
+	if value, removed, ok := ts.getPending(key); ok {
+		if removed {
+			return nil, nerror.New("not found")
+		}
+		return append(make([]byte, 0, len(value)), value...), nil
+	}
+
+	var value, missing, ok = ts.cached(key)
+	if ok {
+		if missing {
+			return nil, nerror.New("not found")
+		}
+		return value, nil
+	}
+
+	value, err := ts.l2.Get(key)
+	if err != nil {
+		// only cache key as missing if L2 confirms it.
+		if exists, existErr := ts.l2.Exists(key); existErr == nil && !exists {
+			ts.cacheMissing(key)
+		}
+		return nil, nerror.WrapOnly(err)
+	}
+
+	ts.cache(key, value)
+	return value, nil
+}
+
+// GetAnyKeys returns a list of values for any of the key's found, with nil
+// set in place of keys not found.
+func (ts *TieredStore) GetAnyKeys(keys ...string) ([][]byte, error) {
+	var values, _, err = ts.getKeys(keys)
+	return values, err
+}
+
+// GetAllKeys returns a list of values for all giving keys, returning an
+// error if any is not found.
+func (ts *TieredStore) GetAllKeys(keys ...string) ([][]byte, error) {
+	var values, found, err = ts.getKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	for index, key := range keys {
+		if !found[index] {
+			return nil, nerror.New("not found").Add("key", key)
+		}
+	}
+	return values, nil
+}
+
+// Save saves giving key and value into the store.
+func (ts *TieredStore) Save(key string, value []byte) error {
+	var cm = append(make([]byte, 0, len(value)), value...)
+	if ts.ops.Mode == WriteBehind {
+		return ts.queue(key, cm, false, false)
+	}
+
+	if err := ts.l2.Save(key, cm); err != nil {
+		ts.invalidateKeys(key)
+		return nerror.WrapOnly(err)
+	}
+	ts.cache(key, cm)
+	return nil
+}
+
+// Update updates the value of an existing key.
+func (ts *TieredStore) Update(key string, value []byte) error {
+	var cm = append(make([]byte, 0, len(value)), value...)
+	if ts.ops.Mode == WriteBehind {
+		var exists, err = ts.Exists(key)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return nerror.New("not found")
+		}
+		return ts.queue(key, cm, true, false)
+	}
+
+	if err := ts.l2.Update(key, cm); err != nil {
+		ts.invalidateKeys(key)
+		return nerror.WrapOnly(err)
+	}
+	ts.cache(key, cm)
+	return nil
+}
+
+// Remove removes giving key from both stores, returning it's value.
+func (ts *TieredStore) Remove(key string) ([]byte, error) {
+	if ts.ops.Mode == WriteBehind {
+		var value, err = ts.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		if err := ts.queue(key, nil, false, true); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+
+	var value, err = ts.l2.Remove(key)
+	ts.invalidateKeys(key)
+	if err != nil {
+		return nil, nerror.WrapOnly(err)
+	}
+	return value, nil
+}
+
+// RemoveKeys removes all giving keys from both stores.
+func (ts *TieredStore) RemoveKeys(keys ...string) error {
+	if ts.ops.Mode == WriteBehind {
+		for _, key := range keys {
+			if err := ts.queue(key, nil, false, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var err = ts.l2.RemoveKeys(keys...)
+	ts.invalidateKeys(keys...)
+	if err != nil {
+		return nerror.WrapOnly(err)
+	}
+	return nil
+}
+
+// *****************************************************
+// internal methods
+// *****************************************************
+
+// getKeys returns values of giving keys, reading all keys not cached
+// from L2 at once.
+func (ts *TieredStore) getKeys(keys []string) ([][]byte, []bool, error) {
+	var values = make([][]byte, len(keys))
+	var found = make([]bool, len(keys))
+
+	var misses []string
+	var missIndexes []int
+	for index, key := range keys {
+		if value, removed, ok := ts.getPending(key); ok {
+			values[index], found[index] = value, !removed
+			continue
+		}
+		if value, missing, ok := ts.cached(key); ok {
+			values[index], found[index] = value, !missing
+			continue
+		}
+		misses = append(misses, key)
+		missIndexes = append(missIndexes, index)
+	}
+
+	if len(misses) == 0 {
+		return values, found, nil
+	}
+
+	var loaded, err = ts.l2.GetAnyKeys(misses...)
+	if err != nil {
+		return nil, nil, nerror.WrapOnly(err)
+	}
+
+	for index, value := range loaded {
+		var key = misses[index]
+		if value == nil {
+			ts.cacheMissing(key)
+			continue
+		}
+
+		values[missIndexes[index]] = value
+		found[missIndexes[index]] = true
+		ts.cache(key, value)
+	}
+	return values, found, nil
+}
+
+// cached returns the value of giving key in L1, with missing true
+// if the key is cached as missing from L2.
+func (ts *TieredStore) cached(key string) (value []byte, missing bool, ok bool) {
+	var data, err = ts.l1.Get(key)
+	if err != nil || len(data) == 0 {
+		return nil, false, false
+	}
+	if data[0] == missingMark {
+		return nil, true, true
+	}
+	return data[1:], false, true
+}
+
+func (ts *TieredStore) cache(key string, value []byte) {
+	var data = make([]byte, 0, len(value)+1)
+	data = append(data, valueMark)
+	data = append(data, value...)
+
+	// a failed cache write must not leave a stale value behind.
+	if err := ts.l1.SaveTTL(key, data, ts.ops.TTL); err != nil {
+		ts.invalidateKeys(key)
+	}
+}
+
+func (ts *TieredStore) cacheMissing(key string) {
+	if ts.ops.NegativeTTL <= 0 {
+		return
+	}
+	if err := ts.l1.SaveTTL(key, []byte{missingMark}, ts.ops.NegativeTTL); err != nil {
+		ts.invalidateKeys(key)
+	}
+}
+
+func (ts *TieredStore) invalidateKeys(keys ...string) {
+	_ = ts.l1.RemoveKeys(keys...)
+}
+
+// invalidate removes all keys changed in L2 from L1 till
+// giving channel is closed.
+func (ts *TieredStore) invalidate(events <-chan nstorage.Event) {
+	defer ts.waiter.Done()
+
+	for event := range events {
+		// queued writes will be reflected once they reach L2.
+		if _, _, ok := ts.getPending(event.Key); ok {
+			continue
+		}
+		ts.invalidateKeys(event.Key)
+	}
+}
@@ -0,0 +1,257 @@
+package ntiered
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influx6/npkg/nerror"
+	"github.com/influx6/npkg/nstorage/internal/tharness"
+	"github.com/influx6/npkg/nstorage/nmap"
+)
+
+func TestStoreWithTieredStoreRemoveKeys(t *testing.T) {
+	var store, err = NewTieredStore(nmap.NewExprByteStore(100), nmap.NewExprByteStore(100), Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	tharness.TestByteStoreRemoveKeys(t, store)
+}
+
+func TestStoreWithTieredStoreGetAnyKeys(t *testing.T) {
+	var store, err = NewTieredStore(nmap.NewExprByteStore(100), nmap.NewExprByteStore(100), Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	tharness.TestByteStoreGetAnykeys(t, store)
+}
+
+func TestStoreWithTieredStoreGetAllkeys(t *testing.T) {
+	var store, err = NewTieredStore(nmap.NewExprByteStore(100), nmap.NewExprByteStore(100), Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	tharness.TestByteStoreGetAllkeys(t, store)
+}
+
+func TestStoreWithTieredStoreFindEach(t *testing.T) {
+	var store, err = NewTieredStore(nmap.NewExprByteStore(100), nmap.NewExprByteStore(100), Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	tharness.TestByteStoreFindEach(t, store)
+}
+
+func TestStoreWithTieredStoreFindPrefix(t *testing.T) {
+	var store, err = NewTieredStore(nmap.NewExprByteStore(100), nmap.NewExprByteStore(100), Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	tharness.TestByteStoreFindPrefix(t, store)
+}
+
+func TestStoreWithTieredStoreFindAll(t *testing.T) {
+	var store, err = NewTieredStore(nmap.NewExprByteStore(100), nmap.NewExprByteStore(100), Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	tharness.TestByteStoreFindAll(t, store)
+}
+
+func TestStoreWithTieredStore(t *testing.T) {
+	var store, err = NewTieredStore(nmap.NewExprByteStore(100), nmap.NewExprByteStore(100), Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	tharness.TestByteStore(t, store)
+}
+
+func TestStoreWithWriteBehindTieredStore(t *testing.T) {
+	var store, err = NewTieredStore(nmap.NewExprByteStore(100), nmap.NewExprByteStore(100), Options{Mode: WriteBehind})
+	require.NoError(t, err)
+	defer store.Close()
+
+	tharness.TestByteStore(t, store)
+}
+
+func TestTieredStoreReadThrough(t *testing.T) {
+	var l1 = nmap.NewExprByteStore(100)
+	var l2 = nmap.NewExprByteStore(100)
+	require.NoError(t, l2.Save("day", []byte("wrecker")))
+
+	var store, err = NewTieredStore(l1, l2, Options{TTL: time.Minute})
+	require.NoError(t, err)
+	defer store.Close()
+
+	value, err := store.Get("day")
+	require.NoError(t, err)
+	require.Equal(t, "wrecker", string(value))
+
+	// served from L1 once cached.
+	require.NoError(t, l2.Update("day", []byte("tweeter")))
+
+	value, err = store.Get("day")
+	require.NoError(t, err)
+	require.Equal(t, "wrecker", string(value))
+
+	ttl, err := l1.TTL("day")
+	require.NoError(t, err)
+	require.True(t, ttl > 0)
+
+	values, err := store.GetAllKeys("day")
+	require.NoError(t, err)
+	require.Equal(t, "wrecker", string(values[0]))
+}
+
+func TestTieredStoreNegativeCaching(t *testing.T) {
+	var l2 = nmap.NewExprByteStore(100)
+	var store, err = NewTieredStore(nmap.NewExprByteStore(100), l2, Options{NegativeTTL: 50 * time.Millisecond})
+	require.NoError(t, err)
+	defer store.Close()
+
+	_, err = store.Get("day")
+	require.Error(t, err)
+
+	// the key stays missing till the negative entry expires.
+	require.NoError(t, l2.Save("day", []byte("wrecker")))
+
+	exists, err := store.Exists("day")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	values, err := store.GetAnyKeys("day")
+	require.NoError(t, err)
+	require.Nil(t, values[0])
+
+	time.Sleep(60 * time.Millisecond)
+
+	value, err := store.Get("day")
+	require.NoError(t, err)
+	require.Equal(t, "wrecker", string(value))
+
+	// writes through the store replace negative entries.
+	_, err = store.Get("night")
+	require.Error(t, err)
+	require.NoError(t, store.Save("night", []byte("sleeper")))
+
+	value, err = store.Get("night")
+	require.NoError(t, err)
+	require.Equal(t, "sleeper", string(value))
+}
+
+func TestTieredStoreWriteThrough(t *testing.T) {
+	var l1 = nmap.NewExprByteStore(100)
+	var l2 = nmap.NewExprByteStore(100)
+	var store, err = NewTieredStore(l1, l2, Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.NoError(t, store.Save("day", []byte("wrecker")))
+
+	value, err := l2.Get("day")
+	require.NoError(t, err)
+	require.Equal(t, "wrecker", string(value))
+
+	exists, err := l1.Exists("day")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	_, err = store.Remove("day")
+	require.NoError(t, err)
+
+	exists, err = l1.Exists("day")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.Error(t, store.Update("night", []byte("sleeper")))
+}
+
+func TestTieredStoreWriteBehind(t *testing.T) {
+	var l2 = nmap.NewExprByteStore(100)
+	var store, err = NewTieredStore(nmap.NewExprByteStore(100), l2, Options{Mode: WriteBehind})
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, store.Save("day", []byte{byte(i)}))
+	}
+	require.NoError(t, store.Save("night", []byte("sleeper")))
+	require.NoError(t, store.RemoveKeys("night"))
+
+	value, err := store.Get("day")
+	require.NoError(t, err)
+	require.Equal(t, []byte{99}, value)
+
+	require.NoError(t, store.Flush())
+
+	value, err = l2.Get("day")
+	require.NoError(t, err)
+	require.Equal(t, []byte{99}, value)
+
+	exists, err := l2.Exists("night")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, store.Save("noon", []byte("eater")))
+	require.NoError(t, store.Close())
+	require.True(t, nerror.IsAny(store.Save("noon", []byte("eater")), ErrClosed))
+
+	// close flushes queued writes.
+	value, err = l2.Get("noon")
+	require.NoError(t, err)
+	require.Equal(t, "eater", string(value))
+}
+
+func TestTieredStoreWriteBehindErrors(t *testing.T) {
+	var lock sync.Mutex
+	var failed []string
+
+	var store, err = NewTieredStore(nmap.NewExprByteStore(100), nmap.NewExprByteStore(100), Options{
+		Mode: WriteBehind,
+		OnWriteError: func(key string, err error) {
+			lock.Lock()
+			failed = append(failed, key)
+			lock.Unlock()
+		},
+	})
+	require.NoError(t, err)
+	defer store.Close()
+
+	// an update of a key removed before it reached L2 fails.
+	require.NoError(t, store.Save("day", []byte("wrecker")))
+	require.NoError(t, store.Flush())
+	require.NoError(t, store.l2.RemoveKeys("day"))
+	require.NoError(t, store.Update("day", []byte("tweeter")))
+	require.NoError(t, store.Flush())
+
+	lock.Lock()
+	require.Equal(t, []string{"day"}, failed)
+	lock.Unlock()
+}
+
+func TestTieredStoreWatchInvalidation(t *testing.T) {
+	var l1 = nmap.NewExprByteStore(100)
+	var l2 = nmap.NewExprByteStore(100)
+	require.NoError(t, l2.Save("day", []byte("wrecker")))
+
+	var store, err = NewTieredStore(l1, l2, Options{Watch: true})
+	require.NoError(t, err)
+	defer store.Close()
+
+	value, err := store.Get("day")
+	require.NoError(t, err)
+	require.Equal(t, "wrecker", string(value))
+
+	// removal by another writer of L2.
+	_, err = l2.Remove("day")
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		var exists, err = store.Exists("day")
+		return err == nil && !exists
+	}, time.Second, 10*time.Millisecond)
+
+	_, err = NewTieredStore(l1, &struct{ *nmap.ExprByteStore }{l2}, Options{Watch: true})
+	require.Error(t, err)
+}
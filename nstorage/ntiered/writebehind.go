@@ -0,0 +1,124 @@
+package ntiered
+
+import (
+	"github.com/influx6/npkg/nerror"
+)
+
+// tieredWrite is a write queued for L2 in WriteBehind mode.
+type tieredWrite struct {
+	key     string
+	value   []byte
+	update  bool
+	remove  bool
+	version uint64
+
+	// flushed is closed once all writes before it are written.
+	flushed chan struct{}
+}
+
+// pendingWrite is the latest queued write of a key, which reads
+// are served from till it is written to L2.
+type pendingWrite struct {
+	value   []byte
+	removed bool
+	version uint64
+}
+
+// queue records giving write as pending, applies it to L1 and
+// queues it for L2.
+func (ts *TieredStore) queue(key string, value []byte, update bool, remove bool) error {
+	ts.pendingLock.Lock()
+	ts.version++
+	var version = ts.version
+	ts.pending[key] = pendingWrite{value: value, removed: remove, version: version}
+	ts.pendingLock.Unlock()
+
+	if remove {
+		ts.invalidateKeys(key)
+	} else {
+		ts.cache(key, value)
+	}
+
+	var err = ts.enqueue(tieredWrite{
+		key:     key,
+		value:   value,
+		update:  update,
+		remove:  remove,
+		version: version,
+	})
+	if err != nil {
+		ts.clearPending(key, version)
+		ts.invalidateKeys(key)
+		return err
+	}
+	return nil
+}
+
+func (ts *TieredStore) enqueue(write tieredWrite) error {
+	ts.closeLock.RLock()
+	defer ts.closeLock.RUnlock()
+
+	if ts.closed {
+		return ErrClosed
+	}
+
+	ts.writes <- write
+	return nil
+}
+
+// getPending returns the pending write of giving key if any.
+func (ts *TieredStore) getPending(key string) (value []byte, removed bool, ok bool) {
+	if ts.ops.Mode != WriteBehind {
+		return nil, false, false
+	}
+
+	ts.pendingLock.Lock()
+	defer ts.pendingLock.Unlock()
+
+	var write, found = ts.pending[key]
+	return write.value, write.removed, found
+}
+
+// clearPending removes the pending write of giving key if no
+// later write was queued.
+func (ts *TieredStore) clearPending(key string, version uint64) {
+	ts.pendingLock.Lock()
+	defer ts.pendingLock.Unlock()
+
+	if write, ok := ts.pending[key]; ok && write.version == version {
+		delete(ts.pending, key)
+	}
+}
+
+// writeBehind writes all queued writes to L2 in order, till the
+// queue is closed.
+func (ts *TieredStore) writeBehind() {
+	defer ts.waiter.Done()
+
+	for write := range ts.writes {
+		if write.flushed != nil {
+			close(write.flushed)
+			continue
+		}
+
+		var err error
+		switch {
+		case write.remove:
+			err = ts.l2.RemoveKeys(write.key)
+		case write.update:
+			err = ts.l2.Update(write.key, write.value)
+		default:
+			err = ts.l2.Save(write.key, write.value)
+		}
+
+		ts.clearPending(write.key, write.version)
+
+		if err != nil {
+			// L1 may no longer agree with L2.
+			ts.invalidateKeys(write.key)
+			if ts.ops.OnWriteError != nil {
+				ts.ops.OnWriteError(write.key, nerror.WrapOnly(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+//go:build go1.21
+// +build go1.21
+
+package ntyped
+
+import (
+	"bytes"
+	"encoding/gob"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v4"
+
+	"github.com/influx6/npkg"
+	"github.com/influx6/npkg/nerror"
+	"github.com/influx6/npkg/njson"
+	"github.com/influx6/npkg/nunsafe"
+	"github.com/influx6/npkg/nzip"
+)
+
+// Codec defines a type which encodes and decodes values of type T
+// to and from a byte slice.
+type Codec[T any] interface {
+	Encode(T) ([]byte, error)
+	Decode([]byte) (T, error)
+}
+
+// CodecFuncs implements the Codec interface using
+// giving functions.
+type CodecFuncs[T any] struct {
+	EncodeFunc func(T) ([]byte, error)
+	DecodeFunc func([]byte) (T, error)
+}
+
+// Encode encodes giving value using EncodeFunc.
+func (c CodecFuncs[T]) Encode(v T) ([]byte, error) {
+	return c.EncodeFunc(v)
+}
+
+// Decode decodes giving bytes using DecodeFunc.
+func (c CodecFuncs[T]) Decode(b []byte) (T, error) {
+	return c.DecodeFunc(b)
+}
+
+// JSONObject defines the constraint for types encoded by JSONCodec,
+// where the pointer type encodes and decodes itself as a json object.
+type JSONObject[T any] interface {
+	*T
+	npkg.EncodableObject
+	npkg.DecodableObject
+}
+
+// JSONCodec returns a Codec which encodes T as a json object using the
+// njson encoder and decoder.
+func JSONCodec[T any, PT JSONObject[T]]() Codec[T] {
+	return CodecFuncs[T]{
+		EncodeFunc: func(v T) ([]byte, error) {
+			var enc = njson.JSONB()
+			PT(&v).EncodeObject(enc)
+			if err := enc.Err(); err != nil {
+				enc.Release()
+				return nil, nerror.WrapOnly(err)
+			}
+			return nunsafe.String2Bytes(enc.Message()), nil
+		},
+		DecodeFunc: func(b []byte) (T, error) {
+			var v T
+			if err := njson.DecodeBytes(b, PT(&v)); err != nil {
+				return v, nerror.WrapOnly(err)
+			}
+			return v, nil
+		},
+	}
+}
+
+// GobCodec returns a Codec which encodes T using encoding/gob.
+func GobCodec[T any]() Codec[T] {
+	return CodecFuncs[T]{
+		EncodeFunc: func(v T) ([]byte, error) {
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+				return nil, nerror.WrapOnly(err)
+			}
+			return buf.Bytes(), nil
+		},
+		DecodeFunc: func(b []byte) (T, error) {
+			var v T
+			if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&v); err != nil {
+				return v, nerror.WrapOnly(err)
+			}
+			return v, nil
+		},
+	}
+}
+
+// MsgpackCodec returns a Codec which encodes T as msgpack.
+func MsgpackCodec[T any]() Codec[T] {
+	return CodecFuncs[T]{
+		EncodeFunc: func(v T) ([]byte, error) {
+			var b, err = msgpack.Marshal(v)
+			if err != nil {
+				return nil, nerror.WrapOnly(err)
+			}
+			return b, nil
+		},
+		DecodeFunc: func(b []byte) (T, error) {
+			var v T
+			if err := msgpack.Unmarshal(b, &v); err != nil {
+				return v, nerror.WrapOnly(err)
+			}
+			return v, nil
+		},
+	}
+}
+
+// BytesCodec stores byte slices as they are.
+var BytesCodec Codec[[]byte] = CodecFuncs[[]byte]{
+	EncodeFunc: func(v []byte) ([]byte, error) {
+		return v, nil
+	},
+	DecodeFunc: func(b []byte) ([]byte, error) {
+		return b, nil
+	},
+}
+
+// StringCodec stores strings as their bytes.
+var StringCodec Codec[string] = CodecFuncs[string]{
+	EncodeFunc: func(v string) ([]byte, error) {
+		return []byte(v), nil
+	},
+	DecodeFunc: func(b []byte) (string, error) {
+		return string(b), nil
+	},
+}
+
+// BoolCodec stores booleans using nzip.
+var BoolCodec Codec[bool] = CodecFuncs[bool]{
+	EncodeFunc: func(v bool) ([]byte, error) {
+		return nzip.ZipBool(v, nil)
+	},
+	DecodeFunc: nzip.UnzipBool,
+}
+
+// Int64Codec stores int64 values as varints using nzip.
+var Int64Codec Codec[int64] = CodecFuncs[int64]{
+	EncodeFunc: func(v int64) ([]byte, error) {
+		return nzip.ZipInt(v, nil)
+	},
+	DecodeFunc: nzip.UnzipInt64,
+}
+
+// Uint64Codec stores uint64 values as varints using nzip.
+var Uint64Codec Codec[uint64] = CodecFuncs[uint64]{
+	EncodeFunc: func(v uint64) ([]byte, error) {
+		return nzip.ZipInt(v, nil)
+	},
+	DecodeFunc: nzip.UnzipUint64,
+}
+
+// Float64Codec stores float64 values using nzip.
+var Float64Codec Codec[float64] = CodecFuncs[float64]{
+	EncodeFunc: func(v float64) ([]byte, error) {
+		return nzip.ZipFloat64(v, nil)
+	},
+	DecodeFunc: nzip.UnzipFloat64,
+}
+
+// TimeCodec stores time values in time.RFC3339Nano format using nzip.
+var TimeCodec Codec[time.Time] = CodecFuncs[time.Time]{
+	EncodeFunc: func(v time.Time) ([]byte, error) {
+		return nzip.ZipTimeWithFormat(time.RFC3339Nano, v, nil)
+	},
+	DecodeFunc: func(b []byte) (time.Time, error) {
+		return nzip.UnzipTimeWithFormat(b, time.RFC3339Nano)
+	},
+}
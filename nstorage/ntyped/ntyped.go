@@ -0,0 +1,185 @@
+//go:build go1.21
+// +build go1.21
+
+// Package ntyped provides typed stores over nstorage stores, using
+// a Codec to convert values to and from their stored bytes.
+//
+// The package uses generics and builds only with Go 1.21 or later.
+package ntyped
+
+import (
+	"time"
+
+	"github.com/influx6/npkg/nerror"
+	"github.com/influx6/npkg/nstorage"
+)
+
+// TypedStore wraps a nstorage.ByteStore, storing values of type T
+// encoded with a Codec.
+type TypedStore[T any] struct {
+	store nstorage.ByteStore
+	codec Codec[T]
+}
+
+// NewTypedStore returns a new instance of a TypedStore.
+func NewTypedStore[T any](store nstorage.ByteStore, codec Codec[T]) *TypedStore[T] {
+	return &TypedStore[T]{store: store, codec: codec}
+}
+
+// Store returns the underline store.
+func (ts *TypedStore[T]) Store() nstorage.ByteStore {
+	return ts.store
+}
+
+// Count returns the total count of keys in the store.
+func (ts *TypedStore[T]) Count() (int64, error) {
+	return ts.store.Count()
+}
+
+// Keys returns all keys of the store.
+func (ts *TypedStore[T]) Keys() ([]string, error) {
+	return ts.store.Keys()
+}
+
+// Exists returns true/false if giving key exists.
+func (ts *TypedStore[T]) Exists(key string) (bool, error) {
+	return ts.store.Exists(key)
+}
+
+// Save encodes and saves giving value for key.
+func (ts *TypedStore[T]) Save(key string, v T) error {
+	var data, err = ts.encode(key, v)
+	if err != nil {
+		return err
+	}
+	return ts.store.Save(key, data)
+}
+
+// Update encodes and updates giving value for key.
+func (ts *TypedStore[T]) Update(key string, v T) error {
+	var data, err = ts.encode(key, v)
+	if err != nil {
+		return err
+	}
+	return ts.store.Update(key, data)
+}
+
+// Get returns the decoded value of giving key.
+func (ts *TypedStore[T]) Get(key string) (T, error) {
+	var data, err = ts.store.Get(key)
+	if err != nil {
+		var v T
+		return v, err
+	}
+	return ts.decode(key, data)
+}
+
+// GetAllKeys returns the decoded values of all giving keys, returning
+// an error if any is not found.
+func (ts *TypedStore[T]) GetAllKeys(keys ...string) ([]T, error) {
+	var data, err = ts.store.GetAllKeys(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	var values = make([]T, len(data))
+	for index, item := range data {
+		if values[index], err = ts.decode(keys[index], item); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// Remove removes giving key, returning it's decoded value.
+func (ts *TypedStore[T]) Remove(key string) (T, error) {
+	var data, err = ts.store.Remove(key)
+	if err != nil {
+		var v T
+		return v, err
+	}
+	return ts.decode(key, data)
+}
+
+// RemoveKeys removes all giving keys from the store.
+func (ts *TypedStore[T]) RemoveKeys(keys ...string) error {
+	return ts.store.RemoveKeys(keys...)
+}
+
+// Each runs through all keys in the store with their decoded values,
+// stopping at the first value which fails to decode.
+//
+// Return nstorage.ErrJustStop if you want to just stop iterating.
+func (ts *TypedStore[T]) Each(fn func(key string, v T) error) error {
+	return ts.store.Each(func(data []byte, key string) error {
+		var v, err = ts.decode(key, data)
+		if err != nil {
+			return err
+		}
+		return fn(key, v)
+	})
+}
+
+func (ts *TypedStore[T]) encode(key string, v T) ([]byte, error) {
+	var data, err = ts.codec.Encode(v)
+	if err != nil {
+		return nil, nerror.Wrap(err, "failed to encode value").Add("key", key)
+	}
+	return data, nil
+}
+
+func (ts *TypedStore[T]) decode(key string, data []byte) (T, error) {
+	var v, err = ts.codec.Decode(data)
+	if err != nil {
+		return v, nerror.Wrap(err, "failed to decode value").Add("key", key)
+	}
+	return v, nil
+}
+
+// ExpirableTypedStore wraps a nstorage.ExpirableStore, storing values
+// of type T encoded with a Codec.
+type ExpirableTypedStore[T any] struct {
+	*TypedStore[T]
+	store nstorage.ExpirableStore
+}
+
+// NewExpirableTypedStore returns a new instance of a ExpirableTypedStore.
+func NewExpirableTypedStore[T any](store nstorage.ExpirableStore, codec Codec[T]) *ExpirableTypedStore[T] {
+	return &ExpirableTypedStore[T]{
+		TypedStore: NewTypedStore[T](store, codec),
+		store:      store,
+	}
+}
+
+// TTL returns the remaining time before giving key expires.
+func (ts *ExpirableTypedStore[T]) TTL(key string) (time.Duration, error) {
+	return ts.store.TTL(key)
+}
+
+// ExtendTTL extends the expiration of giving key by provided duration.
+func (ts *ExpirableTypedStore[T]) ExtendTTL(key string, expiration time.Duration) error {
+	return ts.store.ExtendTTL(key, expiration)
+}
+
+// ResetTTL resets the expiration of giving key to provided duration.
+func (ts *ExpirableTypedStore[T]) ResetTTL(key string, expiration time.Duration) error {
+	return ts.store.ResetTTL(key, expiration)
+}
+
+// SaveTTL encodes and saves giving value for key with giving expiration.
+func (ts *ExpirableTypedStore[T]) SaveTTL(key string, v T, expiration time.Duration) error {
+	var data, err = ts.encode(key, v)
+	if err != nil {
+		return err
+	}
+	return ts.store.SaveTTL(key, data, expiration)
+}
+
+// UpdateTTL encodes and updates giving value for key with giving expiration.
+func (ts *ExpirableTypedStore[T]) UpdateTTL(key string, v T, expiration time.Duration) error {
+	var data, err = ts.encode(key, v)
+	if err != nil {
+		return err
+	}
+	return ts.store.UpdateTTL(key, data, expiration)
+}
@@ -0,0 +1,146 @@
+//go:build go1.21
+// +build go1.21
+
+package ntyped
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influx6/npkg"
+	"github.com/influx6/npkg/nstorage"
+	"github.com/influx6/npkg/nstorage/nmap"
+)
+
+type user struct {
+	Name string
+	Age  int
+}
+
+func (u *user) EncodeObject(enc npkg.ObjectEncoder) {
+	enc.String("name", u.Name)
+	enc.Int("age", u.Age)
+}
+
+func (u *user) DecodeKey(dec npkg.Decoder, k string) error {
+	switch k {
+	case "name":
+		return dec.String(&u.Name)
+	case "age":
+		return dec.Int(&u.Age)
+	}
+	return nil
+}
+
+func testCodec[T any](t *testing.T, codec Codec[T], values map[string]T) {
+	var store = NewTypedStore[T](nmap.NewExprByteStore(100), codec)
+
+	for key, value := range values {
+		require.NoError(t, store.Save(key, value))
+	}
+
+	for key, value := range values {
+		var found, err = store.Get(key)
+		require.NoError(t, err)
+		require.Equal(t, value, found)
+	}
+
+	var seen = map[string]T{}
+	require.NoError(t, store.Each(func(key string, v T) error {
+		seen[key] = v
+		return nil
+	}))
+	require.Equal(t, values, seen)
+}
+
+func TestTypedStoreCodecs(t *testing.T) {
+	var users = map[string]user{
+		"user-1": {Name: "thunder", Age: 32},
+		"user-2": {Name: "lightning", Age: 20},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		testCodec(t, JSONCodec[user](), users)
+	})
+
+	t.Run("gob", func(t *testing.T) {
+		testCodec(t, GobCodec[user](), users)
+	})
+
+	t.Run("msgpack", func(t *testing.T) {
+		testCodec(t, MsgpackCodec[user](), users)
+	})
+
+	t.Run("primitives", func(t *testing.T) {
+		testCodec(t, StringCodec, map[string]string{"a": "thunder", "b": ""})
+		testCodec(t, BytesCodec, map[string][]byte{"a": []byte("thunder")})
+		testCodec(t, BoolCodec, map[string]bool{"a": true, "b": false})
+		testCodec(t, Int64Codec, map[string]int64{"a": -20, "b": 1 << 40})
+		testCodec(t, Uint64Codec, map[string]uint64{"a": 20, "b": 1 << 63})
+		testCodec(t, Float64Codec, map[string]float64{"a": 20.5, "b": -0.25})
+		testCodec(t, TimeCodec, map[string]time.Time{"a": time.Date(2020, 10, 1, 5, 30, 10, 500, time.UTC)})
+	})
+}
+
+func TestTypedStore(t *testing.T) {
+	var store = NewTypedStore[user](nmap.NewExprByteStore(100), JSONCodec[user]())
+
+	require.NoError(t, store.Save("user-1", user{Name: "thunder", Age: 32}))
+	require.NoError(t, store.Save("user-2", user{Name: "lightning", Age: 20}))
+	require.NoError(t, store.Update("user-1", user{Name: "thunder", Age: 33}))
+
+	var values, err = store.GetAllKeys("user-1", "user-2")
+	require.NoError(t, err)
+	require.Equal(t, []user{{Name: "thunder", Age: 33}, {Name: "lightning", Age: 20}}, values)
+
+	var count int
+	require.NoError(t, store.Each(func(key string, v user) error {
+		count++
+		return nstorage.ErrJustStop
+	}))
+	require.Equal(t, 1, count)
+
+	removed, err := store.Remove("user-1")
+	require.NoError(t, err)
+	require.Equal(t, "thunder", removed.Name)
+
+	_, err = store.Get("user-1")
+	require.Error(t, err)
+}
+
+func TestTypedStoreDecodeFailure(t *testing.T) {
+	var raw = nmap.NewExprByteStore(100)
+	require.NoError(t, raw.Save("user-1", []byte("{")))
+
+	var store = NewTypedStore[user](raw, JSONCodec[user]())
+
+	var _, err = store.Get("user-1")
+	require.Error(t, err)
+	require.Error(t, store.Each(func(key string, v user) error {
+		return nil
+	}))
+}
+
+func TestExpirableTypedStore(t *testing.T) {
+	var store = NewExpirableTypedStore[int64](nmap.NewExprByteStore(100), Int64Codec)
+
+	require.NoError(t, store.SaveTTL("count", 20, time.Second))
+
+	var ttl, err = store.TTL("count")
+	require.NoError(t, err)
+	require.True(t, ttl > 0)
+
+	require.NoError(t, store.UpdateTTL("count", 21, 2*time.Second))
+
+	value, err := store.Get("count")
+	require.NoError(t, err)
+	require.Equal(t, int64(21), value)
+
+	require.NoError(t, store.ResetTTL("count", 0))
+
+	ttl, err = store.TTL("count")
+	require.NoError(t, err)
+	require.True(t, ttl <= 0)
+}